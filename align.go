@@ -0,0 +1,384 @@
+package linediff
+
+// Pair identifies aligned elements by their index in sequences A and B.
+// An index of -1 means that the element has no counterpart in the other sequence.
+type Pair struct {
+	A, B int
+}
+
+// Matched returns true if both sides of the pair are present.
+func (p Pair) Matched() bool {
+	return p.A >= 0 && p.B >= 0
+}
+
+// Align computes a minimal alignment of two sequences with the given lengths, using equal to compare elements by index.
+// Equal elements are paired, and unmatched elements are returned with -1 as the index of the missing side.
+// Within a run of unmatched elements, removals from A are always returned before additions from B.
+func Align(lenA, lenB int, equal func(a, b int) bool) []Pair {
+	pairs := alignRange(make([]Pair, 0, max(lenA, lenB)), 0, lenA, 0, lenB, equal)
+	return orderUnmatched(pairs)
+}
+
+// AlignStrings aligns two string sequences by equality.
+func AlignStrings(a, b []string) []Pair {
+	return Align(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+}
+
+// PairChanges merges runs of removals and additions in an alignment, so the first removal is paired with the first addition, and so on.
+// Any excess removals or additions in a run remain unmatched.
+func PairChanges(pairs []Pair) []Pair {
+	result := make([]Pair, 0, len(pairs))
+	for i := 0; i < len(pairs); {
+		if pairs[i].Matched() {
+			result = append(result, pairs[i])
+			i++
+			continue
+		}
+		var removed, added []int
+		removed, added, i = unmatchedRun(pairs, i)
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			p := Pair{A: -1, B: -1}
+			if j < len(removed) {
+				p.A = removed[j]
+			}
+			if j < len(added) {
+				p.B = added[j]
+			}
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// PairSimilar merges runs of removals and additions in an alignment by pairing elements that are similar enough.
+// The similarity function scores a removal from A and an addition to B from 0 to 1, and elements are paired if their similarity
+// is greater than 0 and at least threshold. Pairs in a run are chosen to maximize the total similarity while keeping both sequences in order.
+// Unpaired elements remain unmatched, with removals returned before additions between paired elements.
+//
+// Every removal in a run is scored against every addition, so the cost grows with the product of the run lengths.
+func PairSimilar(pairs []Pair, threshold float64, similarity func(a, b int) float64) []Pair {
+	result := make([]Pair, 0, len(pairs))
+	for i := 0; i < len(pairs); {
+		if pairs[i].Matched() {
+			result = append(result, pairs[i])
+			i++
+			continue
+		}
+		var removed, added []int
+		removed, added, i = unmatchedRun(pairs, i)
+		result = pairSimilarRun(result, removed, added, threshold, similarity)
+	}
+	return result
+}
+
+// unmatchedRun collects the removals and additions in the run of unmatched pairs starting at i, and returns the index after the run.
+func unmatchedRun(pairs []Pair, i int) (removed, added []int, next int) {
+	for ; i < len(pairs) && !pairs[i].Matched(); i++ {
+		if pairs[i].A >= 0 {
+			removed = append(removed, pairs[i].A)
+		} else {
+			added = append(added, pairs[i].B)
+		}
+	}
+	return removed, added, i
+}
+
+func pairSimilarRun(result []Pair, removed, added []int, threshold float64, similarity func(a, b int) float64) []Pair {
+	n, m := len(removed), len(added)
+	// score[i][j] is the best total similarity of pairs within removed[i:] and added[j:].
+	var (
+		score = make([][]float64, n+1)
+		sim   = make([][]float64, n)
+	)
+	score[n] = make([]float64, m+1)
+	for i := n - 1; i >= 0; i-- {
+		score[i] = make([]float64, m+1)
+		sim[i] = make([]float64, m)
+		for j := m - 1; j >= 0; j-- {
+			s := similarity(removed[i], added[j])
+			sim[i][j] = s
+			best := max(score[i+1][j], score[i][j+1])
+			if s > 0 && s >= threshold {
+				best = max(best, s+score[i+1][j+1])
+			}
+			score[i][j] = best
+		}
+	}
+
+	var (
+		i, j    int
+		pending []Pair
+	)
+	for i < n && j < m {
+		s := sim[i][j]
+		switch {
+		case s > 0 && s >= threshold && score[i][j] == s+score[i+1][j+1]:
+			result = append(result, pending...)
+			pending = pending[:0]
+			result = append(result, Pair{A: removed[i], B: added[j]})
+			i++
+			j++
+		case score[i][j] == score[i+1][j]:
+			result = append(result, Pair{A: removed[i], B: -1})
+			i++
+		default:
+			pending = append(pending, Pair{A: -1, B: added[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, Pair{A: removed[i], B: -1})
+	}
+	result = append(result, pending...)
+	for ; j < m; j++ {
+		result = append(result, Pair{A: -1, B: added[j]})
+	}
+	return result
+}
+
+// alignLimit is the largest combined length aligned by alignTrace. Longer ranges are split at the middle snake first, so
+// memory stays linear in the input size no matter how different the sequences are.
+const alignLimit = 512
+
+// alignRange appends the pairs for a[aStart:aEnd] and b[bStart:bEnd], matching any common prefix and suffix directly.
+func alignRange(pairs []Pair, aStart, aEnd, bStart, bEnd int, equal func(a, b int) bool) []Pair {
+	for aStart < aEnd && bStart < bEnd && equal(aStart, bStart) {
+		pairs = append(pairs, Pair{A: aStart, B: bStart})
+		aStart++
+		bStart++
+	}
+	suffix := 0
+	for aStart < aEnd-suffix && bStart < bEnd-suffix && equal(aEnd-suffix-1, bEnd-suffix-1) {
+		suffix++
+	}
+
+	pairs = alignMiddle(pairs, aStart, aEnd-suffix, bStart, bEnd-suffix, equal)
+	for i := 0; i < suffix; i++ {
+		pairs = append(pairs, Pair{A: aEnd - suffix + i, B: bEnd - suffix + i})
+	}
+	return pairs
+}
+
+// alignMiddle finds the shortest edit script between a[aStart:aEnd] and b[bStart:bEnd], appending the resulting pairs.
+// The ranges are expected to have no common prefix or suffix.
+func alignMiddle(pairs []Pair, aStart, aEnd, bStart, bEnd int, equal func(a, b int) bool) []Pair {
+	n, m := aEnd-aStart, bEnd-bStart
+	if n == 0 || m == 0 {
+		return appendUnmatched(pairs, aStart, aEnd, bStart, bEnd)
+	}
+	if n+m <= alignLimit {
+		return alignTrace(pairs, aStart, aEnd, bStart, bEnd, equal)
+	}
+
+	x, y, ok := middleSnake(aStart, aEnd, bStart, bEnd, equal)
+	if !ok {
+		return appendUnmatched(pairs, aStart, aEnd, bStart, bEnd)
+	}
+	pairs = alignRange(pairs, aStart, aStart+x, bStart, bStart+y, equal)
+	return alignRange(pairs, aStart+x, aEnd, bStart+y, bEnd, equal)
+}
+
+// middleSnake runs Myers' algorithm forward from the start and backward from the end of the ranges at the same time,
+// returning the offsets where the two paths meet. The returned ok is false if the ranges have nothing in common.
+func middleSnake(aStart, aEnd, bStart, bEnd int, equal func(a, b int) bool) (x, y int, ok bool) {
+	var (
+		n, m    = aEnd - aStart, bEnd - bStart
+		maxD    = (n + m + 1) / 2
+		offset  = maxD + 1
+		forward = make([]int, 2*offset+1)
+		reverse = make([]int, 2*offset+1)
+		delta   = n - m
+		odd     = delta%2 != 0
+	)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	// The start and end adjustments skip diagonals that have run off the edge of the edit graph.
+	var fStart, fEnd, rStart, rEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x1 int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x1 = forward[offset+k+1]
+			} else {
+				x1 = forward[offset+k-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && equal(aStart+x1, bStart+y1) {
+				x1++
+				y1++
+			}
+			forward[offset+k] = x1
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case odd:
+				rk := offset + delta - k
+				if rk >= 0 && rk < len(reverse) && reverse[rk] != -1 && x1 >= n-reverse[rk] {
+					return x1, y1, true
+				}
+			}
+		}
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			var x2 int
+			if k == -d || (k != d && reverse[offset+k-1] < reverse[offset+k+1]) {
+				x2 = reverse[offset+k+1]
+			} else {
+				x2 = reverse[offset+k-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && equal(aEnd-x2-1, bEnd-y2-1) {
+				x2++
+				y2++
+			}
+			reverse[offset+k] = x2
+			switch {
+			case x2 > n:
+				rEnd += 2
+			case y2 > m:
+				rStart += 2
+			case !odd:
+				fk := offset + delta - k
+				if fk >= 0 && fk < len(forward) && forward[fk] != -1 {
+					x1 := forward[fk]
+					if x1 >= n-x2 {
+						return x1, x1 - (fk - offset), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// alignTrace uses Myers' algorithm to find the shortest edit script between a[aStart:aEnd] and b[bStart:bEnd], appending the
+// resulting pairs. Each step keeps only the diagonals it reached, so memory grows with the square of the edit distance.
+func alignTrace(pairs []Pair, aStart, aEnd, bStart, bEnd int, equal func(a, b int) bool) []Pair {
+	var (
+		n, m   = aEnd - aStart, bEnd - bStart
+		maxD   = n + m
+		offset = maxD
+		v      = make([]int, 2*maxD+2)
+		// trace[d] holds the furthest x reached on diagonals -d to d after step d.
+		trace [][]int
+	)
+search:
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && equal(aStart+x, bStart+y) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	// Walk the trace backward to recover the edit script.
+	var (
+		x, y    = n, m
+		reverse []Pair
+	)
+	for d := len(trace); d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reverse = append(reverse, Pair{A: aStart + x, B: bStart + y})
+		}
+		if x == prevX {
+			y--
+			reverse = append(reverse, Pair{A: -1, B: bStart + y})
+		} else {
+			x--
+			reverse = append(reverse, Pair{A: aStart + x, B: -1})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reverse = append(reverse, Pair{A: aStart + x, B: bStart + y})
+	}
+
+	return appendOrdered(pairs, reverse)
+}
+
+// appendOrdered appends the reversed edit script in forward order, moving removals ahead of additions within each unmatched run.
+func appendOrdered(pairs []Pair, reverse []Pair) []Pair {
+	var added []Pair
+	for i := len(reverse) - 1; i >= 0; i-- {
+		p := reverse[i]
+		switch {
+		case p.Matched():
+			pairs = append(pairs, added...)
+			added = added[:0]
+			pairs = append(pairs, p)
+		case p.A >= 0:
+			pairs = append(pairs, p)
+		default:
+			added = append(added, p)
+		}
+	}
+	return append(pairs, added...)
+}
+
+// orderUnmatched moves removals ahead of additions within each run of unmatched pairs, where the middle snake split a run in two.
+func orderUnmatched(pairs []Pair) []Pair {
+	var added []Pair
+	for i := 0; i < len(pairs); {
+		if pairs[i].Matched() {
+			i++
+			continue
+		}
+		w := i
+		added = added[:0]
+		for ; i < len(pairs) && !pairs[i].Matched(); i++ {
+			if pairs[i].A >= 0 {
+				pairs[w] = pairs[i]
+				w++
+			} else {
+				added = append(added, pairs[i])
+			}
+		}
+		copy(pairs[w:i], added)
+	}
+	return pairs
+}
+
+func appendUnmatched(pairs []Pair, aStart, aEnd, bStart, bEnd int) []Pair {
+	for i := aStart; i < aEnd; i++ {
+		pairs = append(pairs, Pair{A: i, B: -1})
+	}
+	for i := bStart; i < bEnd; i++ {
+		pairs = append(pairs, Pair{A: -1, B: i})
+	}
+	return pairs
+}
@@ -0,0 +1,196 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAlignStrings(t *testing.T) {
+	tests := map[string]struct {
+		A     string
+		B     string
+		Pairs []Pair
+	}{
+		"Empty": {
+			Pairs: []Pair{},
+		},
+		"Same": {
+			A:     "abc",
+			B:     "abc",
+			Pairs: []Pair{{0, 0}, {1, 1}, {2, 2}},
+		},
+		"All added": {
+			A:     "",
+			B:     "ab",
+			Pairs: []Pair{{-1, 0}, {-1, 1}},
+		},
+		"All removed": {
+			A:     "ab",
+			B:     "",
+			Pairs: []Pair{{0, -1}, {1, -1}},
+		},
+		"Inserted middle": {
+			A:     "ad",
+			B:     "abcd",
+			Pairs: []Pair{{0, 0}, {-1, 1}, {-1, 2}, {1, 3}},
+		},
+		"Removed middle": {
+			A:     "abcd",
+			B:     "ad",
+			Pairs: []Pair{{0, 0}, {1, -1}, {2, -1}, {3, 1}},
+		},
+		"Replaced middle": {
+			A:     "axd",
+			B:     "ayd",
+			Pairs: []Pair{{0, 0}, {1, -1}, {-1, 1}, {2, 2}},
+		},
+		"Long insertion": {
+			A:     "abz",
+			B:     "a123456789bz",
+			Pairs: []Pair{{0, 0}, {-1, 1}, {-1, 2}, {-1, 3}, {-1, 4}, {-1, 5}, {-1, 6}, {-1, 7}, {-1, 8}, {-1, 9}, {1, 10}, {2, 11}},
+		},
+		"Interleaved": {
+			A:     "abcabba",
+			B:     "cbabac",
+			Pairs: []Pair{{0, -1}, {1, -1}, {2, 0}, {-1, 1}, {3, 2}, {4, 3}, {5, -1}, {6, 4}, {-1, 5}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			a, b := strings.Split(tc.A, ""), strings.Split(tc.B, "")
+			pairs := AlignStrings(a, b)
+			assert.Equal(t, tc.Pairs, pairs)
+		})
+	}
+}
+
+func TestAlign_Large(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tokens := func(n int) []string {
+		s := make([]string, n)
+		for i := range s {
+			s[i] = strconv.Itoa(rng.Intn(8))
+		}
+		return s
+	}
+	a, b := tokens(1500), tokens(1700)
+
+	pairs := AlignStrings(a, b)
+	assertValidAlignment(t, a, b, pairs)
+	matched := 0
+	for _, p := range pairs {
+		if p.Matched() {
+			matched++
+		}
+	}
+	assert.Equal(t, lcsLength(a, b), matched, "Alignment should be minimal")
+}
+
+func TestAlign_VeryDifferent(t *testing.T) {
+	const n = 5000
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+	// Keep a few common elements so the search can't stop early.
+	for i := 0; i < n; i += 1000 {
+		b[n-i-1] = a[i]
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	pairs := AlignStrings(a, b)
+	runtime.ReadMemStats(&after)
+
+	assertValidAlignment(t, a, b, pairs)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(32<<20), "Memory should stay linear in the input size")
+}
+
+// assertValidAlignment checks that pairs cover both sequences in order, only match equal elements, and list removals before
+// additions within each unmatched run.
+func assertValidAlignment(t *testing.T, a, b []string, pairs []Pair) {
+	t.Helper()
+	var i, j int
+	for n, p := range pairs {
+		switch {
+		case p.Matched():
+			assert.Equal(t, Pair{A: i, B: j}, p)
+			assert.Equal(t, a[p.A], b[p.B])
+			i, j = p.A+1, p.B+1
+		case p.A >= 0:
+			assert.Equal(t, i, p.A)
+			if n > 0 && !pairs[n-1].Matched() {
+				assert.True(t, pairs[n-1].A >= 0, "Removal at %d should come before additions", n)
+			}
+			i++
+		default:
+			assert.Equal(t, j, p.B)
+			j++
+		}
+	}
+	assert.Equal(t, len(a), i)
+	assert.Equal(t, len(b), j)
+}
+
+func lcsLength(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestPairChanges(t *testing.T) {
+	pairs := []Pair{{0, 0}, {1, -1}, {2, -1}, {-1, 1}, {3, 2}, {-1, 3}}
+	expected := []Pair{{0, 0}, {1, 1}, {2, -1}, {3, 2}, {-1, 3}}
+	assert.Equal(t, expected, PairChanges(pairs))
+}
+
+func TestPairSimilar(t *testing.T) {
+	var (
+		a = []string{"same", "apple pie", "zebra", "cherry tart", "end"}
+		b = []string{"same", "quokka", "apple tart", "cherry tart!", "end"}
+	)
+	// Elements are similar if they share a first word.
+	similarity := func(i, j int) float64 {
+		if strings.Fields(a[i])[0] == strings.Fields(b[j])[0] {
+			return 0.5
+		}
+		return 0
+	}
+	pairs := AlignStrings(a, b)
+
+	tests := map[string]struct {
+		threshold float64
+		expected  []Pair
+	}{
+		"Pairs similar elements in order": {
+			threshold: 0.5,
+			expected:  []Pair{{0, 0}, {-1, 1}, {1, 2}, {2, -1}, {3, 3}, {4, 4}},
+		},
+		"Threshold above similarity": {
+			threshold: 0.6,
+			expected:  []Pair{{0, 0}, {1, -1}, {2, -1}, {3, -1}, {-1, 1}, {-1, 2}, {-1, 3}, {4, 4}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, PairSimilar(pairs, tc.threshold, similarity))
+		})
+	}
+}
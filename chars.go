@@ -0,0 +1,41 @@
+package linediff
+
+import (
+	"github.com/rivo/uniseg"
+)
+
+// SplitRunes splits input into individual runes, for character level diffs of text without combining characters or emoji.
+var SplitRunes = NextFunc(func(tr *TokenReader) (string, bool) {
+	r, ok := tr.next()
+	if !ok {
+		return "", false
+	}
+	return string(r), true
+})
+
+// SplitGraphemes splits input into extended grapheme clusters, as described in UAX #29, for character level diffs.
+// Each token is a user-perceived character, so emoji with modifiers, flags, and letters with combining accents aren't split into pieces.
+var SplitGraphemes = SplitterFunc(func(tr *TokenReader) []string {
+	var (
+		tokens  []string
+		pending string
+		state   = -1
+	)
+	for {
+		r, ok := tr.next()
+		if !ok {
+			break
+		}
+		pending += string(r)
+		// A cluster boundary is only certain once the rune following it has been read.
+		cluster, rest, _, newState := uniseg.FirstGraphemeClusterInString(pending, state)
+		if len(rest) > 0 {
+			tokens = append(tokens, cluster)
+			pending, state = rest, newState
+		}
+	}
+	if len(pending) > 0 {
+		tokens = append(tokens, pending)
+	}
+	return tokens
+})
@@ -0,0 +1,70 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitRunes(t *testing.T) {
+	assert.Nil(t, SplitRunes.Split(NewStringTokenReader("")))
+	assert.Equal(t, []string{"a", "é", " ", "語"}, SplitRunes.Split(NewStringTokenReader("aé 語")))
+}
+
+func TestSplitGraphemes(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens []string
+	}{
+		"No tokens": {
+			input:  "",
+			tokens: nil,
+		},
+		"ASCII": {
+			input:  "ab c",
+			tokens: []string{"a", "b", " ", "c"},
+		},
+		"Combining accent": {
+			input:  "café!",
+			tokens: []string{"c", "a", "f", "é", "!"},
+		},
+		"Skin tone modifier": {
+			input:  "hi 👋🏽",
+			tokens: []string{"h", "i", " ", "👋🏽"},
+		},
+		"Flags": {
+			input:  "🇯🇵🇫🇷",
+			tokens: []string{"🇯🇵", "🇫🇷"},
+		},
+		"ZWJ sequence": {
+			input:  "👩‍👩‍👧x",
+			tokens: []string{"👩‍👩‍👧", "x"},
+		},
+		"CRLF": {
+			input:  "a\r\nb",
+			tokens: []string{"a", "\r\n", "b"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := SplitGraphemes.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+		})
+	}
+}
+
+func TestSplitGraphemes_LongInput(t *testing.T) {
+	input := strings.Repeat("é🇫🇷", 100)
+	tokens := SplitGraphemes.Split(NewStringTokenReaderWithSize(input, 16))
+	assert.Len(t, tokens, 200)
+	assert.Equal(t, input, strings.Join(tokens, ""))
+}
+
+func TestDiffSplit_Graphemes(t *testing.T) {
+	ds := DiffSplit("thumbs 👍🏻", "thumbs 👍🏿", SplitGraphemes)
+	assert.Equal(t, "thumbs [-👍🏻-]{+👍🏿+}", ds.WordDiff())
+
+	ds = DiffSplit("thumbs 👍🏻", "thumbs 👍🏿", SplitRunes)
+	assert.Equal(t, "thumbs 👍[-🏻-]{+🏿+}", ds.WordDiff())
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"github.com/drognisep/linediff/internal/cli"
+	"github.com/drognisep/linediff/internal/report"
+	flag "github.com/spf13/pflag"
+	"log"
+	"strings"
+)
+
+// writeText writes input text that was transformed by a command.
+// Text from files is written as is, while text from arguments is written on its own line.
+func (o *options) writeText(text string) error {
+	if !o.Files {
+		text += "\n"
+	}
+	return o.writeOutput(text)
+}
+
+var diffCommand = &command{
+	name:    "diff",
+	args:    "[flags] A B",
+	summary: "Diffs two strings, or two files with 'files', and prints the diff with a renderer.",
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addDiffFlags(flags)
+		opts.addOutFlag(flags)
+		var render string
+		flags.StringVarP(&render, "render", "r", "word", fmt.Sprintf("Specifies how the diff is rendered, one of %s.", strings.Join(cli.RenderFormats, ", ")))
+		return func(args []string) (int, error) {
+			differ, err := opts.differ()
+			if err != nil {
+				return 0, err
+			}
+			inputs, err := opts.inputs(args, 2)
+			if err != nil {
+				return 0, err
+			}
+			ds := differ.Diff(inputs[0], inputs[1])
+			output, err := cli.Render(ds, render)
+			if err != nil {
+				return 0, fmt.Errorf("%w: %v", errUsage, err)
+			}
+			if err := opts.writeOutput(output + "\n"); err != nil {
+				return 0, err
+			}
+			if ds.Stats().Changed() {
+				return report.ExitDifferent, nil
+			}
+			return report.ExitIdentical, nil
+		}
+	},
+}
+
+var patchCommand = &command{
+	name: "patch",
+	args: "[flags] create A B | apply PATCH INPUT",
+	summary: "Creates a token patch as JSON from the diff of A and B, or applies a patch file to INPUT. " +
+		"Patches are verified against the input, so a patch that doesn't match is an error rather than a bad result.",
+	actions: []string{"create", "apply"},
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addDiffFlags(flags)
+		opts.addOutFlag(flags)
+		var reverse bool
+		flags.BoolVarP(&reverse, "reverse", "R", false, "Reverts the patch when applying it, producing A from B.")
+		return func(args []string) (int, error) {
+			if len(args) == 0 {
+				return 0, fmt.Errorf("%w: expected 'create' or 'apply'", errUsage)
+			}
+			switch args[0] {
+			case "create":
+				differ, err := opts.differ()
+				if err != nil {
+					return 0, err
+				}
+				inputs, err := opts.inputs(args[1:], 2)
+				if err != nil {
+					return 0, err
+				}
+				data, err := json.Marshal(differ.Diff(inputs[0], inputs[1]))
+				if err != nil {
+					return 0, err
+				}
+				return report.ExitIdentical, opts.writeOutput(string(data) + "\n")
+			case "apply":
+				if len(args) != 3 {
+					return 0, fmt.Errorf("%w: expected a patch file and an input, got %d arguments", errUsage, len(args)-1)
+				}
+				data, err := readInput(args[1])
+				if err != nil {
+					return 0, err
+				}
+				ds := new(linediff.DiffSet)
+				if err := json.Unmarshal([]byte(data), ds); err != nil {
+					return 0, fmt.Errorf("failed to parse patch '%s': %w", args[1], err)
+				}
+				inputs, err := opts.inputs(args[2:], 1)
+				if err != nil {
+					return 0, err
+				}
+				var output string
+				if reverse {
+					output, err = ds.Revert(inputs[0])
+				} else {
+					output, err = ds.Apply(inputs[0])
+				}
+				if err != nil {
+					return 0, err
+				}
+				return report.ExitIdentical, opts.writeText(output)
+			default:
+				return 0, fmt.Errorf("%w: unknown patch action '%s', expected 'create' or 'apply'", errUsage, args[0])
+			}
+		}
+	},
+}
+
+var mergeCommand = &command{
+	name: "merge",
+	args: "[flags] BASE OURS THEIRS",
+	summary: "Merges the changes from BASE to OURS and from BASE to THEIRS token by token. " +
+		"Conflicting changes are written between conflict markers like git, and the exit code is 1 if there are any.",
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addDiffFlags(flags)
+		opts.addOutFlag(flags)
+		return func(args []string) (int, error) {
+			differ, err := opts.differ()
+			if err != nil {
+				return 0, err
+			}
+			inputs, err := opts.inputs(args, 3)
+			if err != nil {
+				return 0, err
+			}
+			result := differ.Merge(inputs[0], inputs[1], inputs[2])
+			if err := opts.writeText(result.String()); err != nil {
+				return 0, err
+			}
+			if conflicts := result.Conflicts(); conflicts > 0 {
+				log.Printf("%d conflict(s) found\n", conflicts)
+				return report.ExitDifferent, nil
+			}
+			return report.ExitIdentical, nil
+		}
+	},
+}
+
+var jsonCommand = &command{
+	name: "json",
+	args: "[flags] A B",
+	summary: "Compares two JSON documents structurally, so key order and formatting are ignored, and prints each change by JSON path. " +
+		"Changed strings are token diffed, and the exit code is 1 if there are differences.",
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addDiffFlags(flags)
+		opts.addOutFlag(flags)
+		var patch bool
+		flags.BoolVar(&patch, "patch", false, "Prints the changes as an RFC 6902 JSON Patch instead.")
+		return func(args []string) (int, error) {
+			differ, err := opts.differ()
+			if err != nil {
+				return 0, err
+			}
+			inputs, err := opts.inputs(args, 2)
+			if err != nil {
+				return 0, err
+			}
+			diff, err := differ.DiffJSON([]byte(inputs[0]), []byte(inputs[1]))
+			if err != nil {
+				return 0, err
+			}
+			output := diff.String()
+			if patch {
+				data, err := diff.Patch()
+				if err != nil {
+					return 0, err
+				}
+				output = string(data) + "\n"
+			}
+			if err := opts.writeOutput(output); err != nil {
+				return 0, err
+			}
+			if diff.Changed() {
+				return report.ExitDifferent, nil
+			}
+			return report.ExitIdentical, nil
+		}
+	},
+}
+
+var statsCommand = &command{
+	name:    "stats",
+	args:    "[flags] A B",
+	summary: "Prints the number of same, added, and removed tokens between A and B, and their similarity.",
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addDiffFlags(flags)
+		opts.addOutFlag(flags)
+		var asJSON bool
+		flags.BoolVar(&asJSON, "json", false, "Prints the stats as a JSON object.")
+		return func(args []string) (int, error) {
+			differ, err := opts.differ()
+			if err != nil {
+				return 0, err
+			}
+			inputs, err := opts.inputs(args, 2)
+			if err != nil {
+				return 0, err
+			}
+			stats := differ.Diff(inputs[0], inputs[1]).Stats()
+			var output string
+			if asJSON {
+				data, err := json.Marshal(stats)
+				if err != nil {
+					return 0, err
+				}
+				output = string(data) + "\n"
+			} else {
+				output = fmt.Sprintf("same:       %d\nadded:      %d\nremoved:    %d\nsimilarity: %.1f%%\n", stats.Same, stats.Added, stats.Removed, stats.Similarity*100)
+			}
+			return report.ExitIdentical, opts.writeOutput(output)
+		}
+	},
+}
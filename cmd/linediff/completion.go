@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	flag "github.com/spf13/pflag"
+	"strings"
+)
+
+// completionShells are the shells that completion scripts can be generated for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var completionCommand = &command{
+	name: "completion",
+	args: "SHELL",
+	summary: fmt.Sprintf("Prints a completion script for one of %s, generated from the flags of each command. ", strings.Join(completionShells, ", ")) +
+		"For example, add 'source <(linediff completion bash)' to .bashrc.",
+	actions: completionShells,
+	setup: func(flags *flag.FlagSet, opts *options) runFunc {
+		opts.addOutFlag(flags)
+		return func(args []string) (int, error) {
+			if len(args) != 1 {
+				return 0, fmt.Errorf("%w: expected a shell name", errUsage)
+			}
+			var script string
+			switch args[0] {
+			case "bash":
+				script = bashCompletion()
+			case "zsh":
+				script = "#compdef linediff\n\nautoload -U +X bashcompinit && bashcompinit\n\n" + bashCompletion()
+			case "fish":
+				script = fishCompletion()
+			default:
+				return 0, fmt.Errorf("%w: unknown shell '%s', expected one of %s", errUsage, args[0], strings.Join(completionShells, ", "))
+			}
+			return 0, opts.writeOutput(script)
+		}
+	},
+}
+
+// commandNames returns the name of each command, including help.
+func commandNames() []string {
+	names := make([]string, 0, len(commands)+1)
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return append(names, "help")
+}
+
+// flagNames returns the long and short forms of each of the command's flags.
+func flagNames(cmd *command) []string {
+	var names []string
+	cmd.flags().VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+		if len(f.Shorthand) > 0 {
+			names = append(names, "-"+f.Shorthand)
+		}
+	})
+	return names
+}
+
+func bashCompletion() string {
+	var cases strings.Builder
+	for _, cmd := range commands {
+		fmt.Fprintf(&cases, "\t%s)\n\t\topts=\"%s\"\n", cmd.name, strings.Join(flagNames(cmd), " "))
+		if len(cmd.actions) > 0 {
+			fmt.Fprintf(&cases, "\t\tactions=\"%s\"\n", strings.Join(cmd.actions, " "))
+		}
+		cases.WriteString("\t\t;;\n")
+	}
+	return fmt.Sprintf(`_linediff() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" opts="" actions=""
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return
+	fi
+	case "${COMP_WORDS[1]}" in
+	help)
+		COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+		return
+		;;
+%s	esac
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+	elif [ -n "$actions" ] && [ "$COMP_CWORD" -eq 2 ]; then
+		COMPREPLY=($(compgen -W "$actions" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+
+complete -o filenames -F _linediff linediff
+`, strings.Join(commandNames(), " "), cases.String())
+}
+
+func fishCompletion() string {
+	var buf strings.Builder
+	buf.WriteString("complete -c linediff -n '__fish_use_subcommand' -a help -d 'Prints usage information for a command.'\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&buf, "complete -c linediff -n '__fish_use_subcommand' -a %s -d %s\n", cmd.name, fishQuote(firstSentence(cmd.summary)))
+		fmt.Fprintf(&buf, "complete -c linediff -f -n '__fish_seen_subcommand_from help' -a %s\n", cmd.name)
+		seen := fmt.Sprintf("'__fish_seen_subcommand_from %s'", cmd.name)
+		for _, action := range cmd.actions {
+			fmt.Fprintf(&buf, "complete -c linediff -n %s -a %s\n", seen, action)
+		}
+		cmd.flags().VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(&buf, "complete -c linediff -n %s -l %s", seen, f.Name)
+			if len(f.Shorthand) > 0 {
+				fmt.Fprintf(&buf, " -s %s", f.Shorthand)
+			}
+			if f.Value.Type() != "bool" {
+				buf.WriteString(" -r")
+			}
+			fmt.Fprintf(&buf, " -d %s\n", fishQuote(firstSentence(f.Usage)))
+		})
+	}
+	return buf.String()
+}
+
+// firstSentence shortens a description to its first sentence, for shells that show descriptions beside each completion.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
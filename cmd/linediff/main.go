@@ -0,0 +1,164 @@
+// Command linediff diffs, patches, and merges text token by token, and generates diff reports.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff/internal/report"
+	flag "github.com/spf13/pflag"
+	"log"
+	"os"
+	"strings"
+)
+
+// errUsage is wrapped by errors that should be followed by the command's usage.
+var errUsage = errors.New("usage error")
+
+// runFunc runs a command with its positional arguments, returning the exit code.
+type runFunc func(args []string) (int, error)
+
+// command is a linediff subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// actions are the names of nested actions, like 'create' and 'apply' for patch, which are offered in completions.
+	actions []string
+	// setup registers the command's flags, and returns the function that runs it.
+	setup func(flags *flag.FlagSet, opts *options) runFunc
+	// main runs commands that parse their own flags instead, like report.
+	main func(name string, args []string) int
+	// flagSet describes the flags of commands with main.
+	flagSet func(name string) *flag.FlagSet
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		diffCommand,
+		{
+			name:    "report",
+			args:    "[flags] [A B]",
+			summary: "Generates HTML, JSON, or CSV diff reports, like diffhtml.",
+			main:    report.Main,
+			flagSet: report.FlagSet,
+		},
+		patchCommand,
+		mergeCommand,
+		jsonCommand,
+		statsCommand,
+		completionCommand,
+	}
+}
+
+// findCommand returns the command with the given name, or nil if there isn't one.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// newFlagSet creates the command's flag set, and the function that runs it.
+func (c *command) newFlagSet(opts *options) (*flag.FlagSet, runFunc) {
+	name := "linediff " + c.name
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.BoolVarP(&opts.Help, "help", "h", false, "Prints this usage information.")
+	run := c.setup(flags, opts)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n\n%s\n\nFLAGS:\n%s", name, c.args, c.summary, flags.FlagUsages())
+	}
+	return flags, run
+}
+
+// flags returns the command's flag set, without running it.
+func (c *command) flags() *flag.FlagSet {
+	if c.flagSet != nil {
+		return c.flagSet("linediff " + c.name)
+	}
+	flags, _ := c.newFlagSet(new(options))
+	return flags
+}
+
+// run parses the command's flags and runs it, returning the exit code.
+func (c *command) run(args []string) int {
+	if c.main != nil {
+		return c.main("linediff "+c.name, args)
+	}
+	opts := new(options)
+	flags, run := c.newFlagSet(opts)
+	if err := flags.Parse(args); err != nil {
+		return report.ExitError
+	}
+	if opts.Help {
+		flags.Usage()
+		return report.ExitIdentical
+	}
+	code, err := run(flags.Args())
+	if err != nil {
+		log.Println(err)
+		if errors.Is(err, errUsage) {
+			flags.Usage()
+		}
+		return report.ExitError
+	}
+	return code
+}
+
+func usage() {
+	var buf strings.Builder
+	for _, cmd := range commands {
+		fmt.Fprintf(&buf, "  %-11s %s\n", cmd.name, firstSentence(cmd.summary))
+	}
+	fmt.Fprintf(os.Stderr, `Usage: linediff COMMAND [flags] [args]
+
+Diffs, patches, and merges text token by token.
+
+COMMANDS:
+%s  help        Prints usage information for a command.
+
+Run 'linediff help COMMAND' for the flags of each command.
+
+EXIT CODES:
+  diff, patch, merge, json, and stats exit with 0 for success, and 2 for errors.
+  diff and json also exit with 1 if differences were found, and merge with 1 if there are conflicts, like diff and git merge-file.
+  report follows the diffhtml exit codes.
+`, buf.String())
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches the command line to a subcommand, returning the exit code.
+func run(cmdline []string) int {
+	if len(cmdline) == 0 {
+		usage()
+		return report.ExitError
+	}
+	name, args := cmdline[0], cmdline[1:]
+	switch name {
+	case "help", "-h", "--help":
+		if len(args) == 0 {
+			usage()
+			return report.ExitIdentical
+		}
+		cmd := findCommand(args[0])
+		if cmd == nil {
+			log.Printf("unknown command '%s'\n", args[0])
+			usage()
+			return report.ExitError
+		}
+		return cmd.run([]string{"--help"})
+	}
+	cmd := findCommand(name)
+	if cmd == nil {
+		log.Printf("unknown command '%s'\n", name)
+		usage()
+		return report.ExitError
+	}
+	return cmd.run(args)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/drognisep/linediff"
+	"github.com/drognisep/linediff/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile writes a file in a temporary directory, and returns its path.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// runTestCommand runs the command line with its output written to a temporary file, and returns the exit code and output.
+func runTestCommand(t *testing.T, cmdline ...string) (int, string) {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "out")
+	code := run(append(cmdline, "--out", out))
+	data, err := os.ReadFile(out)
+	if os.IsNotExist(err) {
+		return code, ""
+	}
+	require.NoError(t, err)
+	return code, string(data)
+}
+
+func TestRun_Diff(t *testing.T) {
+	var (
+		a = writeTestFile(t, "a.txt", "one two three\n")
+		b = writeTestFile(t, "b.txt", "one 2 three\n")
+	)
+	tests := map[string]struct {
+		cmdline []string
+		code    int
+		output  string
+	}{
+		"Arguments":      {cmdline: []string{"diff", "a b", "a c"}, code: report.ExitDifferent, output: "a [-b-]{+c+}\n"},
+		"Identical":      {cmdline: []string{"diff", "a b", "a b"}, code: report.ExitIdentical, output: "a b\n"},
+		"Files":          {cmdline: []string{"diff", "-f", a, b}, code: report.ExitDifferent, output: "one [-two-]{+2+} three\n\n"},
+		"Renderer":       {cmdline: []string{"diff", "-r", "markers", "a b", "a c"}, code: report.ExitDifferent, output: "a (--b--)(++c++)\n"},
+		"Unknown render": {cmdline: []string{"diff", "-r", "unified", "a", "b"}, code: report.ExitError},
+		"Missing input":  {cmdline: []string{"diff", "a"}, code: report.ExitError},
+		"Missing file":   {cmdline: []string{"diff", "-f", a, filepath.Join(t.TempDir(), "missing")}, code: report.ExitError},
+		"Invalid flags":  {cmdline: []string{"diff", "--words", "--lines", "a", "b"}, code: report.ExitError},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, output := runTestCommand(t, tc.cmdline...)
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestRun_Patch(t *testing.T) {
+	var (
+		a = writeTestFile(t, "a.txt", "one\r\ntwo\r\nthree\r\n")
+		b = writeTestFile(t, "b.txt", "one\n2\nthree\n")
+	)
+	code, patch := runTestCommand(t, "patch", "create", "-f", "--lines", "--ignore-eol", a, b)
+	require.Equal(t, report.ExitIdentical, code)
+	ds := new(linediff.DiffSet)
+	require.NoError(t, json.Unmarshal([]byte(patch), ds), "The patch should be a JSON DiffSet")
+	patchFile := writeTestFile(t, "patch.json", patch)
+
+	code, output := runTestCommand(t, "patch", "apply", "-f", patchFile, a)
+	assert.Equal(t, report.ExitIdentical, code)
+	assert.Equal(t, "one\n2\nthree\n", output)
+
+	code, output = runTestCommand(t, "patch", "apply", "-f", "-R", patchFile, b)
+	assert.Equal(t, report.ExitIdentical, code)
+	assert.Equal(t, "one\r\ntwo\r\nthree\r\n", output)
+
+	code, _ = runTestCommand(t, "patch", "apply", "-f", patchFile, b)
+	assert.Equal(t, report.ExitError, code, "A patch that doesn't match the input should be an error")
+
+	code, _ = runTestCommand(t, "patch", "apply", "-f", writeTestFile(t, "bad.json", "{"), a)
+	assert.Equal(t, report.ExitError, code, "An invalid patch should be an error")
+
+	code, _ = runTestCommand(t, "patch", "revert", a, b)
+	assert.Equal(t, report.ExitError, code, "Unknown actions should be an error")
+}
+
+func TestRun_Merge(t *testing.T) {
+	base := writeTestFile(t, "base.txt", "a b c")
+	tests := map[string]struct {
+		ours, theirs string
+		code         int
+		output       string
+	}{
+		"Clean": {
+			ours:   "a B c",
+			theirs: "a b C",
+			code:   report.ExitIdentical,
+			output: "a B C",
+		},
+		"Conflict": {
+			ours:   "a B c",
+			theirs: "a X c",
+			code:   report.ExitDifferent,
+			output: "a <<<<<<< ours\nB\n=======\nX\n>>>>>>> theirs\n c",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ours := writeTestFile(t, "ours.txt", tc.ours)
+			theirs := writeTestFile(t, "theirs.txt", tc.theirs)
+			code, output := runTestCommand(t, "merge", "-f", base, ours, theirs)
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	var (
+		a = writeTestFile(t, "a.json", `{"a": 1, "b": "x y"}`)
+		b = writeTestFile(t, "b.json", `{"b": "x z", "a": 2}`)
+	)
+	code, output := runTestCommand(t, "json", "-f", a, b)
+	assert.Equal(t, report.ExitDifferent, code)
+	assert.Equal(t, "~ $.a: 1 -> 2\n~ $.b: x [-y-]{+z+}\n", output)
+
+	code, output = runTestCommand(t, "json", "-f", "--patch", a, b)
+	assert.Equal(t, report.ExitDifferent, code)
+	assert.JSONEq(t, `[{"op": "replace", "path": "/a", "value": 2}, {"op": "replace", "path": "/b", "value": "x z"}]`, output)
+
+	code, output = runTestCommand(t, "json", `{"a": [1, 2]}`, `{ "a": [1, 2] }`)
+	assert.Equal(t, report.ExitIdentical, code)
+	assert.Empty(t, output)
+
+	code, _ = runTestCommand(t, "json", "{", "{}")
+	assert.Equal(t, report.ExitError, code, "Invalid JSON should be an error")
+}
+
+func TestRun_Stats(t *testing.T) {
+	code, output := runTestCommand(t, "stats", "a b c d", "a x c d")
+	assert.Equal(t, report.ExitIdentical, code, "Stats should succeed even if there are differences")
+	assert.Equal(t, "same:       6\nadded:      1\nremoved:    1\nsimilarity: 85.7%\n", output)
+
+	code, output = runTestCommand(t, "stats", "--json", "a b c d", "a x c d")
+	assert.Equal(t, report.ExitIdentical, code)
+	var stats linediff.DiffStats
+	require.NoError(t, json.Unmarshal([]byte(output), &stats))
+	assert.Equal(t, 6, stats.Same)
+	assert.Equal(t, 1, stats.Added)
+	assert.Equal(t, 1, stats.Removed)
+}
+
+func TestRun_Completion(t *testing.T) {
+	tests := map[string][]string{
+		"bash": {"complete -o filenames -F _linediff linediff", `opts="--buffer`, `actions="create apply"`},
+		"zsh":  {"#compdef linediff", "bashcompinit", "complete -o filenames -F _linediff linediff"},
+		"fish": {"complete -c linediff -n '__fish_use_subcommand' -a diff", "-l ignore-eol", "-l render -s r -r"},
+	}
+
+	for shell, contains := range tests {
+		t.Run(shell, func(t *testing.T) {
+			code, output := runTestCommand(t, "completion", shell)
+			require.Equal(t, report.ExitIdentical, code)
+			for _, s := range contains {
+				assert.Contains(t, output, s)
+			}
+			for _, cmd := range commandNames() {
+				assert.Contains(t, output, cmd, "Each command should be completed")
+			}
+		})
+	}
+
+	code, _ := runTestCommand(t, "completion", "powershell")
+	assert.Equal(t, report.ExitError, code, "Unknown shells should be an error")
+}
+
+func TestRun_Usage(t *testing.T) {
+	assert.Equal(t, report.ExitError, run(nil))
+	assert.Equal(t, report.ExitError, run([]string{"unknown"}))
+	assert.Equal(t, report.ExitIdentical, run([]string{"help"}))
+	assert.Equal(t, report.ExitIdentical, run([]string{"help", "diff"}))
+	assert.Equal(t, report.ExitError, run([]string{"help", "unknown"}))
+	assert.Equal(t, "One.", firstSentence("One. Two. Three"))
+}
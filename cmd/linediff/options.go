@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"github.com/drognisep/linediff"
+	"github.com/drognisep/linediff/internal/cli"
+	flag "github.com/spf13/pflag"
+	"io"
+	"os"
+)
+
+// options are parsed by each command, though each command only registers the flags it uses.
+type options struct {
+	cli.DiffOptions
+	Help    bool
+	Files   bool
+	OutFile string
+}
+
+// addDiffFlags registers the shared diff options, and the files option for reading inputs from files.
+func (o *options) addDiffFlags(flags *flag.FlagSet) {
+	o.DiffOptions.AddFlags(flags)
+	flags.BoolVarP(&o.Files, "files", "f", false, "Reads each input from the file given as an argument, instead of using the argument itself. Use '-' to read from STDIN.")
+}
+
+// addOutFlag registers the output file option.
+func (o *options) addOutFlag(flags *flag.FlagSet) {
+	flags.StringVarP(&o.OutFile, "out", "o", "", "Writes output to a file instead of STDOUT.")
+}
+
+// differ validates the diff options, and creates a Differ with them.
+func (o *options) differ() (*linediff.Differ, error) {
+	if err := o.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return o.Differ(), nil
+}
+
+// inputs returns exactly n inputs from the arguments, reading them from files with the files option.
+func (o *options) inputs(args []string, n int) ([]string, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("%w: expected %d arguments, got %d", errUsage, n, len(args))
+	}
+	if !o.Files {
+		return args, nil
+	}
+	inputs := make([]string, n)
+	for i, arg := range args {
+		text, err := readInput(arg)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = text
+	}
+	return inputs, nil
+}
+
+// readInput reads the named file, or STDIN if name is '-'.
+func readInput(name string) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input '%s': %w", name, err)
+	}
+	return string(data), nil
+}
+
+// writeOutput writes the output to the output file, or STDOUT if there isn't one.
+func (o *options) writeOutput(output string) error {
+	if len(o.OutFile) == 0 {
+		_, err := io.WriteString(os.Stdout, output)
+		return err
+	}
+	if err := os.WriteFile(o.OutFile, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write output file '%s': %w", o.OutFile, err)
+	}
+	return nil
+}
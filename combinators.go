@@ -0,0 +1,197 @@
+package linediff
+
+import (
+	"strings"
+)
+
+// Matcher reads a single token from the current position of a TokenReader, like a quoted string.
+// If there's no match, then "" and false are returned, and all read runes are unread.
+type Matcher func(tr *TokenReader) (string, bool)
+
+// Quoted matches a string starting and ending with the same rune from quotes, including the quotes.
+// Runes following the escape rune are included without ending the string, and if escape is a quote rune, a doubled quote is an escaped quote, like "say ""hi""" in CSV.
+// Use 0 for no escape rune. An unterminated string doesn't match, and neither does a string longer than the TokenReader buffer,
+// since runes can only be unread that far back.
+func Quoted(quotes string, escape rune) Matcher {
+	return func(tr *TokenReader) (string, bool) {
+		mark := tr.Mark()
+		defer tr.limit(mark)()
+		quote, ok := tr.next()
+		if !ok || !strings.ContainsRune(quotes, quote) {
+			tr.Reset(mark)
+			return "", false
+		}
+		var buf strings.Builder
+		buf.WriteRune(quote)
+		for {
+			r, ok := tr.next()
+			if !ok {
+				tr.Reset(mark)
+				return "", false
+			}
+			buf.WriteRune(r)
+			switch {
+			case r == quote && escape == quote:
+				if doubled, found := tr.AcceptToken(string(quote)); found {
+					buf.WriteString(doubled)
+					continue
+				}
+				return buf.String(), true
+			case r == quote:
+				return buf.String(), true
+			case r == escape && escape != 0:
+				escaped, ok := tr.next()
+				if !ok {
+					tr.Reset(mark)
+					return "", false
+				}
+				buf.WriteRune(escaped)
+			}
+		}
+	}
+}
+
+// Balanced matches a group starting with an opening bracket and ending with its matching closing bracket, including nested groups.
+// Brackets are given as pairs of opening and closing runes, like "()[]{}".
+// Inner matchers are tried at each position in the group, so a bracket within a match, like a quoted string, doesn't open or close a group.
+// An unbalanced group doesn't match, and neither does a group longer than the TokenReader buffer, since runes can only be unread that far back.
+func Balanced(brackets string, inner ...Matcher) Matcher {
+	pairs := []rune(brackets)
+	if len(pairs)%2 != 0 {
+		panic("brackets must be given as pairs")
+	}
+	closers := map[rune]rune{}
+	for i := 0; i < len(pairs); i += 2 {
+		closers[pairs[i]] = pairs[i+1]
+	}
+	return func(tr *TokenReader) (string, bool) {
+		var (
+			buf   strings.Builder
+			mark  = tr.Mark()
+			stack []rune
+		)
+		defer tr.limit(mark)()
+	loop:
+		for {
+			if len(stack) > 0 {
+				for _, m := range inner {
+					if token, ok := m(tr); ok {
+						buf.WriteString(token)
+						continue loop
+					}
+				}
+			}
+			r, ok := tr.next()
+			if !ok {
+				break
+			}
+			buf.WriteRune(r)
+			if closer, ok := closers[r]; ok {
+				stack = append(stack, closer)
+				continue
+			}
+			if len(stack) == 0 {
+				break
+			}
+			if r == stack[len(stack)-1] {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return buf.String(), true
+				}
+				continue
+			}
+			if strings.ContainsRune(brackets, r) {
+				// Mismatched closing bracket.
+				break
+			}
+		}
+		tr.Reset(mark)
+		return "", false
+	}
+}
+
+// Group keeps the text matched by any of the groups together as single tokens, and splits the text between them with split.
+// Groups are tried in order at each position, so a group can start in the middle of what split would consider a token.
+func Group(split Splitter, groups ...Matcher) Splitter {
+	return SplitterFunc(func(tr *TokenReader) []string {
+		var (
+			tokens []string
+			text   strings.Builder
+		)
+		flush := func() {
+			if text.Len() > 0 {
+				tokens = append(tokens, split.Split(newTextTokenReader(text.String()))...)
+				text.Reset()
+			}
+		}
+	loop:
+		for {
+			for _, g := range groups {
+				if token, ok := g(tr); ok {
+					flush()
+					tokens = append(tokens, token)
+					continue loop
+				}
+			}
+			r, ok := tr.next()
+			if !ok {
+				break
+			}
+			text.WriteRune(r)
+		}
+		flush()
+		return tokens
+	})
+}
+
+// Chain splits input with the first splitter, then splits each token again with the next splitter, and so on.
+func Chain(splitters ...Splitter) Splitter {
+	return SplitterFunc(func(tr *TokenReader) []string {
+		if len(splitters) == 0 {
+			return readAllTokens(tr)
+		}
+		tokens := splitters[0].Split(tr)
+		for _, sub := range splitters[1:] {
+			tokens = resplit(tokens, sub, nil)
+		}
+		return tokens
+	})
+}
+
+// SubSplit splits input with split, then splits each token for which match returns true again with sub.
+// Other tokens are kept as they are, so only some kinds of token are broken down further, like sub-splitting words but not quoted strings.
+func SubSplit(split, sub Splitter, match func(token string) bool) Splitter {
+	if match == nil {
+		panic("nil match function")
+	}
+	return SplitterFunc(func(tr *TokenReader) []string {
+		return resplit(split.Split(tr), sub, match)
+	})
+}
+
+// resplit splits each of the tokens with sub if match is nil or returns true.
+func resplit(tokens []string, sub Splitter, match func(token string) bool) []string {
+	var result []string
+	for _, token := range tokens {
+		if match != nil && !match(token) {
+			result = append(result, token)
+			continue
+		}
+		result = append(result, sub.Split(newTextTokenReader(token))...)
+	}
+	return result
+}
+
+// newTextTokenReader creates a TokenReader for text that's already in memory, with a buffer that holds all of it and the end of the input,
+// so splitting it again isn't limited by the default buffer size.
+func newTextTokenReader(text string) *TokenReader {
+	return NewStringTokenReaderWithSize(text, len(text)+1)
+}
+
+// readAllTokens reads the rest of the input as a single token, if there is any.
+func readAllTokens(tr *TokenReader) []string {
+	if s := readAll(tr); len(s) > 0 {
+		return []string{s}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestQuoted(t *testing.T) {
+	tests := map[string]struct {
+		quotes string
+		escape rune
+		input  string
+		token  string
+		found  bool
+	}{
+		"Not quoted": {
+			quotes: `"`,
+			input:  `abc "d"`,
+		},
+		"Quoted": {
+			quotes: `"'`,
+			input:  `'New York, NY' rest`,
+			token:  `'New York, NY'`,
+			found:  true,
+		},
+		"Backslash escape": {
+			quotes: `"`,
+			escape: '\\',
+			input:  `"say \"hi\"" rest`,
+			token:  `"say \"hi\""`,
+			found:  true,
+		},
+		"Doubled quote escape": {
+			quotes: `"`,
+			escape: '"',
+			input:  `"say ""hi""",rest`,
+			token:  `"say ""hi"""`,
+			found:  true,
+		},
+		"Unterminated": {
+			quotes: `"`,
+			escape: '\\',
+			input:  `"open \"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr := NewStringTokenReader(tc.input)
+			token, found := Quoted(tc.quotes, tc.escape)(tr)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.token, token)
+			assert.Equal(t, strings.TrimPrefix(tc.input, tc.token), readAll(tr), "Unmatched input should remain")
+		})
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		token string
+		found bool
+	}{
+		"No bracket": {
+			input: "a(b)",
+		},
+		"Nested": {
+			input: "(a [b {c}] d) e",
+			token: "(a [b {c}] d)",
+			found: true,
+		},
+		"Quoted bracket": {
+			input: `(")", x) y`,
+			token: `(")", x)`,
+			found: true,
+		},
+		"Unbalanced": {
+			input: "(a (b)",
+		},
+		"Mismatched": {
+			input: "(a]",
+		},
+	}
+
+	match := Balanced("()[]{}", Quoted(`"`, '\\'))
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr := NewStringTokenReader(tc.input)
+			token, found := match(tr)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.token, token)
+			assert.Equal(t, strings.TrimPrefix(tc.input, tc.token), readAll(tr), "Unmatched input should remain")
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	split := Group(SplitWords, Quoted(`"`, '"'), Balanced("()"))
+	tokens := split.Split(NewStringTokenReader(`city: "New York, NY", zip (10001, 10002)`))
+	assert.Equal(t, []string{"city", ":", " ", `"New York, NY"`, ",", " ", "zip", " ", "(10001, 10002)"}, tokens)
+
+	ds := DiffSplit(`"New York, NY", US`, `"New York, NY", USA`, Group(SplitWords, Quoted(`"`, 0)))
+	assert.Equal(t, `"New York, NY", [-US-]{+USA+}`, ds.WordDiff())
+}
+
+func TestGroup_LongInput(t *testing.T) {
+	tests := map[string]struct {
+		group Matcher
+		input string
+	}{
+		"Unterminated quote": {
+			group: Quoted(`"`, '\\'),
+			input: `say "` + strings.Repeat("word ", 400) + "end",
+		},
+		"Quote longer than the buffer": {
+			group: Quoted(`"`, '\\'),
+			input: `say "` + strings.Repeat("word ", 400) + `" end`,
+		},
+		"Unbalanced group": {
+			group: Balanced("()", Quoted(`"`, 0)),
+			input: "call (" + strings.Repeat(`x "y" `, 400),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := Group(SplitSpaces, tc.group).Split(NewStringTokenReaderWithSize(tc.input, 64))
+			assert.Equal(t, tc.input, strings.Join(tokens, ""), "No input should be lost")
+		})
+	}
+
+	tokens := Group(SplitSpaces, Quoted(`"`, 0)).Split(NewStringTokenReaderWithSize(`a "b c" `+strings.Repeat("d ", 100), 64))
+	assert.Contains(t, tokens, `"b c"`, "Quotes within the buffer should still match")
+}
+
+func TestChain_LongTokens(t *testing.T) {
+	quoted := `"` + strings.Repeat("a ", 1000) + `"`
+	split := Chain(SplitLines, Group(SplitSpaces, Quoted(`"`, 0)))
+	tokens := split.Split(NewStringTokenReaderWithSize("x "+quoted+" y\nz", 4096))
+	assert.Equal(t, []string{"x", " ", quoted, " ", "y\n", "z"}, tokens, "Tokens should be split again without the default buffer size limit")
+}
+
+func TestChain(t *testing.T) {
+	split := Chain(SplitLines, SplitSpaces)
+	assert.Equal(t, []string{"a", " ", "b\n", "c"}, split.Split(NewStringTokenReader("a b\nc")))
+	assert.Equal(t, []string{"a b"}, Chain().Split(NewStringTokenReader("a b")))
+	assert.Nil(t, Chain().Split(NewStringTokenReader("")))
+}
+
+func TestSubSplit(t *testing.T) {
+	isIdent := func(token string) bool {
+		return !strings.HasPrefix(token, `"`)
+	}
+	camel := MustSplitRegexp(`[A-Z]?[a-z0-9]+|\s+`)
+	split := SubSplit(Group(SplitSpaces, Quoted(`"`, 0)), camel, isIdent)
+	tokens := split.Split(NewStringTokenReader(`parseHeaderName "keepThisString"`))
+	assert.Equal(t, []string{"parse", "Header", "Name", " ", `"keepThisString"`}, tokens)
+
+	assert.Panics(t, func() {
+		SubSplit(SplitSpaces, SplitRunes, nil)
+	})
+}
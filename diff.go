@@ -1,11 +1,15 @@
 package linediff
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/drognisep/runebuffer"
 	"strings"
 )
 
+var ErrInvalidTag = errors.New("invalid tag")
+
 type Tag int
 
 const (
@@ -14,9 +18,47 @@ const (
 	Removed
 )
 
+func (t Tag) String() string {
+	switch t {
+	case Same:
+		return "same"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return fmt.Sprintf("Tag(%d)", int(t))
+	}
+}
+
+func (t Tag) MarshalText() ([]byte, error) {
+	switch t {
+	case Same, Added, Removed:
+		return []byte(t.String()), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTag, int(t))
+	}
+}
+
+func (t *Tag) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "same":
+		*t = Same
+	case "added":
+		*t = Added
+	case "removed":
+		*t = Removed
+	default:
+		return fmt.Errorf("%w: '%s'", ErrInvalidTag, string(text))
+	}
+	return nil
+}
+
 type DiffSet struct {
 	segments []string
 	tags     []Tag
+	// others holds the text in B of Same segments that are equal to their text in A, but not identical, by segment index.
+	others map[int]string
 }
 
 func (s *DiffSet) String() string {
@@ -37,6 +79,32 @@ func (s *DiffSet) String() string {
 	return buf.String()
 }
 
+// WordDiff renders the DiffSet in the word-diff notation used by git, where removed runs are written as [-removed-] and added runs as {+added+}.
+func (s *DiffSet) WordDiff() string {
+	var buf strings.Builder
+	for i, segment := range s.segments {
+		tag := s.tags[i]
+		if i == 0 || s.tags[i-1] != tag {
+			switch tag {
+			case Added:
+				buf.WriteString("{+")
+			case Removed:
+				buf.WriteString("[-")
+			}
+		}
+		buf.WriteString(segment)
+		if i == len(s.segments)-1 || s.tags[i+1] != tag {
+			switch tag {
+			case Added:
+				buf.WriteString("+}")
+			case Removed:
+				buf.WriteString("-]")
+			}
+		}
+	}
+	return buf.String()
+}
+
 func (s *DiffSet) Add(tag Tag, tokens ...string) {
 	if len(tokens) == 0 {
 		return
@@ -61,6 +129,120 @@ func (s *DiffSet) AddSimilarity(tokens ...string) {
 	s.Add(Same, tokens...)
 }
 
+// AddEquivalent adds a Same segment for tokens that are equal but may not be identical, like lines with different line endings.
+// The segment has the text of a, and b is kept so the DiffSet can still be applied and reverted as a patch.
+func (s *DiffSet) AddEquivalent(a, b string) {
+	if a != b {
+		if s.others == nil {
+			s.others = map[int]string{}
+		}
+		s.others[len(s.segments)] = b
+	}
+	s.Add(Same, a)
+}
+
+// textB returns the text in B of the segment at i.
+func (s *DiffSet) textB(i int) string {
+	if other, ok := s.others[i]; ok {
+		return other
+	}
+	return s.segments[i]
+}
+
+// Segment is a single tagged token of a DiffSet, as represented in JSON.
+type Segment struct {
+	Tag  Tag    `json:"tag"`
+	Text string `json:"text"`
+	// B is the text of a Same segment in B, if it isn't identical to Text.
+	B string `json:"b,omitempty"`
+}
+
+// Segments returns a copy of each tagged token in the DiffSet.
+func (s *DiffSet) Segments() []Segment {
+	segments := make([]Segment, len(s.segments))
+	for i, segment := range s.segments {
+		segments[i] = Segment{Tag: s.tags[i], Text: segment}
+		if other, ok := s.others[i]; ok {
+			segments[i].B = other
+		}
+	}
+	return segments
+}
+
+// MarshalJSON encodes the DiffSet as an array of segments like {"tag": "added", "text": "token"}.
+func (s *DiffSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Segments())
+}
+
+// UnmarshalJSON decodes an array of segments, as produced by MarshalJSON, replacing any existing segments in the DiffSet.
+func (s *DiffSet) UnmarshalJSON(data []byte) error {
+	var segments []Segment
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return err
+	}
+	s.segments, s.tags, s.others = nil, nil, nil
+	for _, segment := range segments {
+		if segment.Tag == Same && len(segment.B) > 0 {
+			s.AddEquivalent(segment.Text, segment.B)
+			continue
+		}
+		s.Add(segment.Tag, segment.Text)
+	}
+	return nil
+}
+
+// DiffStats summarizes the tokens of a DiffSet.
+type DiffStats struct {
+	Same    int `json:"same"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// Similarity is the proportion of tokens from both inputs that are the same, from 0 to 1.
+	// Two empty inputs are considered to be completely similar.
+	Similarity float64 `json:"similarity"`
+}
+
+// Changed returns true if any tokens were added or removed.
+func (s DiffStats) Changed() bool {
+	return s.Added > 0 || s.Removed > 0
+}
+
+func (s *DiffSet) Stats() DiffStats {
+	var stats DiffStats
+	for _, tag := range s.tags {
+		switch tag {
+		case Same:
+			stats.Same++
+		case Added:
+			stats.Added++
+		case Removed:
+			stats.Removed++
+		}
+	}
+	stats.updateSimilarity()
+	return stats
+}
+
+// CombineStats adds the token counts of each DiffStats, and calculates the similarity of the combined counts.
+func CombineStats(stats ...DiffStats) DiffStats {
+	var combined DiffStats
+	for _, s := range stats {
+		combined.Same += s.Same
+		combined.Added += s.Added
+		combined.Removed += s.Removed
+	}
+	combined.updateSimilarity()
+	return combined
+}
+
+func (s *DiffStats) updateSimilarity() {
+	total := 2*s.Same + s.Added + s.Removed
+	if total == 0 {
+		s.Similarity = 1
+	} else {
+		s.Similarity = float64(2*s.Same) / float64(total)
+	}
+}
+
 func (s *DiffSet) Iterator() *DiffSetIterator {
 	return &DiffSetIterator{set: s}
 }
@@ -71,21 +253,71 @@ func Diff(a, b string) *DiffSet {
 
 // DiffCrossConfidence determines how many tokens the cross comparison phase will look ahead before giving up.
 // This can be tuned according to the length of the incoming data set to emit more or less verbose diffs.
+// These are the defaults used by package level diff functions and NewDiffer.
 var (
 	DiffCrossConfidence = 3
 	BufferSize          = runebuffer.DefaultBufferSize
 )
 
+// DiffSplit diffs a and b with the given Splitter, using the package level defaults for other options.
 func DiffSplit(a, b string, split Splitter) *DiffSet {
 	if split == nil {
 		panic("nil splitter")
 	}
+	d := NewDiffer(split)
+	return d.Diff(a, b)
+}
+
+// Differ holds the options for diffing inputs.
+// A Differ is safe for concurrent use as long as its fields aren't modified while diffing, so different options may be used at the same time without changing package level defaults.
+type Differ struct {
+	// CrossConfidence determines how many tokens the cross comparison phase will look ahead before giving up.
+	CrossConfidence int
+	// BufferSize is the size of the read buffer for each input in runes.
+	BufferSize int
+	// Splitter splits each input into tokens.
+	Splitter Splitter
+	// Equal determines whether two tokens are the same, so insignificant differences can be ignored, like with EqualIgnoringLineEndings.
+	// Tokens are compared exactly if Equal is nil.
+	Equal func(a, b string) bool
+}
+
+// NewDiffer creates a Differ with the given Splitter, and the current package level defaults for other options.
+func NewDiffer(split Splitter) *Differ {
+	return &Differ{
+		CrossConfidence: DiffCrossConfidence,
+		BufferSize:      BufferSize,
+		Splitter:        split,
+	}
+}
+
+// Diff splits a and b into tokens and diffs them.
+func (d *Differ) Diff(a, b string) *DiffSet {
+	return d.DiffTokens(d.split(a), d.split(b))
+}
+
+// split splits s into tokens with the Differ's Splitter.
+func (d *Differ) split(s string) []string {
+	if d.Splitter == nil {
+		panic("nil splitter")
+	}
+	return d.Splitter.Split(NewStringTokenReaderWithSize(s, d.BufferSize))
+}
+
+// equal compares two tokens with the Equal function, if there is one.
+func (d *Differ) equal(a, b string) bool {
+	if d.Equal == nil {
+		return a == b
+	}
+	return d.Equal(a, b)
+}
 
+// DiffTokens diffs two sequences of tokens that have already been split.
+// Tokens that are equal, but not identical, are included in the DiffSet as they appear in as, and their text in bs is kept for patches.
+func (d *Differ) DiffTokens(as, bs []string) *DiffSet {
 	var (
 		ds      = new(DiffSet)
-		as      = split.Split(NewStringTokenReaderWithSize(a, BufferSize))
 		aOffset int
-		bs      = split.Split(NewStringTokenReaderWithSize(b, BufferSize))
 		bOffset int
 		maxi    = max(len(as), len(bs))
 	)
@@ -106,23 +338,23 @@ loop:
 		}
 
 		// Comparisons
-		if as[ai] == bs[bi] {
-			ds.AddSimilarity(as[ai])
+		if d.equal(as[ai], bs[bi]) {
+			ds.AddEquivalent(as[ai], bs[bi])
 			continue
 		}
 
 		// Cross comparison
-		for j := 1; j <= DiffCrossConfidence; j++ {
-			if bi+j < len(bs) && as[ai] == bs[bi+j] {
+		for j := 1; j <= d.CrossConfidence; j++ {
+			if bi+j < len(bs) && d.equal(as[ai], bs[bi+j]) {
 				ds.AddAddition(bs[bi : bi+j]...)
 				bOffset += j
-				ds.AddSimilarity(as[ai])
+				ds.AddEquivalent(as[ai], bs[bi+j])
 				continue loop
 			}
-			if ai+j < len(as) && bs[bi] == as[ai+j] {
+			if ai+j < len(as) && d.equal(bs[bi], as[ai+j]) {
 				ds.AddRemoval(as[ai : ai+j]...)
 				aOffset += j
-				ds.AddSimilarity(bs[bi])
+				ds.AddEquivalent(as[ai+j], bs[bi])
 				continue loop
 			}
 		}
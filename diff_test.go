@@ -1,7 +1,9 @@
 package linediff
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
 )
 
@@ -175,3 +177,129 @@ func TestDiff_LongStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestTag_MarshalText(t *testing.T) {
+	for _, tag := range []Tag{Same, Added, Removed} {
+		text, err := tag.MarshalText()
+		assert.NoError(t, err)
+		var parsed Tag
+		assert.NoError(t, parsed.UnmarshalText(text))
+		assert.Equal(t, tag, parsed)
+	}
+	_, err := Tag(42).MarshalText()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	var parsed Tag
+	assert.ErrorIs(t, parsed.UnmarshalText([]byte("changed")), ErrInvalidTag)
+}
+
+func TestDiffSet_MarshalJSON(t *testing.T) {
+	ds := Diff("a string here", "some string")
+	data, err := json.Marshal(ds)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"tag": "removed", "text": "a"},
+		{"tag": "added", "text": "some"},
+		{"tag": "same", "text": " "},
+		{"tag": "same", "text": "string"},
+		{"tag": "removed", "text": " "},
+		{"tag": "removed", "text": "here"}
+	]`, string(data))
+
+	parsed := new(DiffSet)
+	assert.NoError(t, json.Unmarshal(data, parsed))
+	assert.Equal(t, ds, parsed)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`[{"tag": "bogus", "text": "a"}]`), parsed), ErrInvalidTag)
+}
+
+func TestDiffSet_Segments(t *testing.T) {
+	segments := Diff("a b", "a c").Segments()
+	assert.Equal(t, []Segment{
+		{Tag: Same, Text: "a"},
+		{Tag: Same, Text: " "},
+		{Tag: Removed, Text: "b"},
+		{Tag: Added, Text: "c"},
+	}, segments)
+}
+
+func TestDiffSet_Stats(t *testing.T) {
+	stats := Diff("a string here", "some string").Stats()
+	assert.Equal(t, 2, stats.Same)
+	assert.Equal(t, 1, stats.Added)
+	assert.Equal(t, 3, stats.Removed)
+	assert.InDelta(t, 0.5, stats.Similarity, 0.0001)
+	assert.True(t, stats.Changed())
+
+	stats = Diff("", "").Stats()
+	assert.Equal(t, 1.0, stats.Similarity)
+	assert.False(t, stats.Changed())
+}
+
+func TestDiffSet_WordDiff(t *testing.T) {
+	tests := map[string]struct {
+		A      string
+		B      string
+		Result string
+	}{
+		"No difference": {
+			A:      "a string here",
+			B:      "a string here",
+			Result: "a string here",
+		},
+		"Replacement and removal": {
+			A:      "a string here",
+			B:      "some string",
+			Result: "[-a-]{+some+} string[- here-]",
+		},
+		"Addition": {
+			A:      "a string",
+			B:      "a string here",
+			Result: "a string{+ here+}",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Result, Diff(tc.A, tc.B).WordDiff())
+		})
+	}
+}
+
+func TestCombineStats(t *testing.T) {
+	combined := CombineStats(Diff("a b", "a c").Stats(), Diff("x", "x").Stats())
+	assert.Equal(t, 3, combined.Same)
+	assert.Equal(t, 1, combined.Added)
+	assert.Equal(t, 1, combined.Removed)
+	assert.InDelta(t, 0.75, combined.Similarity, 0.0001)
+	assert.Equal(t, 1.0, CombineStats().Similarity)
+}
+
+func TestDiffer_Diff(t *testing.T) {
+	d := NewDiffer(SplitSpaces)
+	assert.Equal(t, DiffCrossConfidence, d.CrossConfidence)
+	assert.Equal(t, BufferSize, d.BufferSize)
+
+	d.CrossConfidence = 1
+	assert.Equal(t, "(--a--)(++x++) (--b--)(++a++)(++ ++)(++b++)", d.Diff("a b", "x a b").String())
+	assert.Equal(t, "(++x++)(++ ++)a b", Diff("a b", "x a b").String())
+
+	assert.Panics(t, func() {
+		(&Differ{}).Diff("a", "b")
+	})
+}
+
+func TestDiffer_Concurrent(t *testing.T) {
+	d := NewDiffer(SplitSpaces)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, "a (--string--)(++thing++) here", d.Diff("a string here", "a thing here").String())
+			}
+		}()
+	}
+	wg.Wait()
+}
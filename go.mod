@@ -4,8 +4,11 @@ go 1.22
 
 require (
 	github.com/drognisep/runebuffer v0.0.0-20220520045020-2cd74bd3daf7
+	github.com/rivo/uniseg v0.4.7
 	github.com/saylorsolutions/modmake v0.4.0
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -16,7 +19,5 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/saylorsolutions/cache v1.2.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.18.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
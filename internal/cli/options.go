@@ -0,0 +1,157 @@
+// Package cli holds the options shared by the linediff and diffhtml commands.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff"
+	flag "github.com/spf13/pflag"
+	"unicode/utf8"
+)
+
+// langSplitters are the source code splitters for each language accepted by the lang option.
+var langSplitters = map[string]linediff.Splitter{
+	"go":  linediff.SplitGo,
+	"c":   linediff.SplitCLike,
+	"sql": linediff.SplitSQL,
+}
+
+// DiffOptions are the options shared by every command that diffs text.
+type DiffOptions struct {
+	BufferSize        int
+	LookAheadMatching int
+	Delimiters        string
+	Words             bool
+	Chars             bool
+	Lines             bool
+	TokenRegex        string
+	TokenRegexMode    string
+	Lang              string
+	IgnoreEOL         bool
+	Quotes            string
+	Escape            string
+}
+
+// AddFlags registers the diff options with a flag set.
+func (o *DiffOptions) AddFlags(flags *flag.FlagSet) {
+	flags.IntVar(&o.BufferSize, "buffer", linediff.BufferSize, "Sets the read buffer size for diff samples in runes. This should be greater than or equal to the maximum sample size.")
+	flags.IntVar(&o.LookAheadMatching, "matchahead", linediff.DiffCrossConfidence, "Sets the matching lookahead threshold for diffing. A larger threshold reduces performance, but tends to reduce diff size for inputs with less variance.")
+	flags.StringVar(&o.Delimiters, "delim", " ", "Specifies a custom input token delimiter. Each rune in this string is used to separate input terms for comparison. Defaults to space delimiting terms.")
+	flags.BoolVar(&o.Words, "words", false, "Splits inputs at Unicode word boundaries instead of delimiters, so punctuation and whitespace runs are separate tokens.")
+	flags.BoolVar(&o.Chars, "chars", false, "Splits inputs into user-perceived characters instead of delimiters, for character level diffs.")
+	flags.BoolVar(&o.Lines, "lines", false, "Splits inputs into lines instead of delimiters, keeping each line's terminator. LF, CRLF, CR, and Unicode line separators are recognized.")
+	flags.StringVar(&o.TokenRegex, "token-regex", "", "Splits inputs with a regular expression instead of delimiters, like '[A-Za-z]+|\\d+(\\.\\d+)?|\\S'. By default each match is a token, and text between matches is ignored.")
+	flags.StringVar(&o.TokenRegexMode, "token-regex-mode", "match", "Specifies whether 'token-regex' matches are the tokens with 'match', or separators between tokens with 'split'. Separators are tokens too in 'split' mode, so no text is ignored.")
+	flags.StringVar(&o.Lang, "lang", "", "Splits inputs as source code in a language, one of go, c, or sql, so string literals and comments are single tokens. Use 'c' for other languages with C-like syntax, like Java or JavaScript.")
+	flags.StringVar(&o.Quotes, "quotes", "", "Keeps strings quoted with any of these runes together as single tokens, like '\"' to keep \"New York, NY\" whole. Quotes may be escaped with the 'escape' rune.")
+	flags.StringVar(&o.Escape, "escape", "\\", "Sets the rune that escapes the next rune in strings kept together with 'quotes'. If empty, a quote is escaped by doubling it, like 'it''s' in SQL or \"say \"\"hi\"\"\" in CSV.")
+	flags.BoolVar(&o.IgnoreEOL, "ignore-eol", false, "Ignores changes in line terminators when comparing tokens, like LF and CRLF.")
+}
+
+// Validate checks that the diff options are within their bounds.
+func (o *DiffOptions) Validate() error {
+	if o.BufferSize < 256 {
+		return fmt.Errorf("buffer size %d is below the lower bound of 256", o.BufferSize)
+	}
+	if o.LookAheadMatching < 3 {
+		return fmt.Errorf("lookahead matching threshold %d is below the lower bound of 3", o.LookAheadMatching)
+	}
+	var splitters int
+	for _, set := range []bool{o.Words, o.Chars, o.Lines, len(o.TokenRegex) > 0, len(o.Lang) > 0} {
+		if set {
+			splitters++
+		}
+	}
+	if splitters > 1 {
+		return errors.New("only one of 'words', 'chars', 'lines', 'token-regex', or 'lang' may be used")
+	}
+	if _, ok := langSplitters[o.Lang]; len(o.Lang) > 0 && !ok {
+		return fmt.Errorf("unknown language '%s', expected one of go, c, or sql", o.Lang)
+	}
+	if utf8.RuneCountInString(o.Escape) > 1 {
+		return fmt.Errorf("escape '%s' must be a single rune, or empty to escape quotes by doubling them", o.Escape)
+	}
+	if _, err := o.regexpMode(); err != nil {
+		return err
+	}
+	if len(o.TokenRegex) > 0 {
+		if _, err := linediff.SplitRegexp(o.TokenRegex); err != nil {
+			return fmt.Errorf("invalid token regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// Differ creates a Differ with the configured options, so the package level defaults aren't modified.
+func (o *DiffOptions) Differ() *linediff.Differ {
+	d := &linediff.Differ{
+		CrossConfidence: o.LookAheadMatching,
+		BufferSize:      o.BufferSize,
+		Splitter:        o.Splitter(),
+	}
+	if o.IgnoreEOL {
+		d.Equal = linediff.EqualIgnoringLineEndings
+	}
+	return d
+}
+
+// Splitter returns the Splitter for the options, keeping quoted strings together as single tokens with the quotes option.
+// Without an escape rune, each quote rune escapes itself, so doubled quotes don't end a string.
+func (o *DiffOptions) Splitter() linediff.Splitter {
+	split := o.baseSplitter()
+	if len(o.Quotes) == 0 {
+		return split
+	}
+	if escape, _ := utf8.DecodeRuneInString(o.Escape); len(o.Escape) > 0 {
+		return linediff.Group(split, linediff.Quoted(o.Quotes, escape))
+	}
+	var groups []linediff.Matcher
+	for _, quote := range o.Quotes {
+		groups = append(groups, linediff.Quoted(string(quote), quote))
+	}
+	return linediff.Group(split, groups...)
+}
+
+// baseSplitter returns a Splitter that separates tokens at each of the delimiter runes, keeping delimiters as tokens.
+// With the words, chars, or lines options, inputs are split at Unicode word, grapheme cluster, or line boundaries instead.
+// With the token regex or lang options, they're split by the regular expression or as source code.
+func (o *DiffOptions) baseSplitter() linediff.Splitter {
+	switch {
+	case len(o.TokenRegex) > 0:
+		mode, _ := o.regexpMode()
+		s := linediff.MustSplitRegexp(o.TokenRegex)
+		s.Mode = mode
+		return s
+	case o.Words:
+		return linediff.SplitWords
+	case o.Chars:
+		return linediff.SplitGraphemes
+	case o.Lines:
+		return linediff.SplitLines
+	case len(o.Lang) > 0:
+		return langSplitters[o.Lang]
+	}
+	delimiters := linediff.NewRuneSet(o.Delimiters)
+	return linediff.NextFunc(func(tr *linediff.TokenReader) (string, bool) {
+		if token, found := tr.UntilFunc(delimiters.Contains); found {
+			return token, true
+		}
+		// Each delimiter is a token by itself.
+		if delim := tr.Peek(1); len(delim) > 0 {
+			return tr.AcceptToken(delim)
+		}
+		return "", false
+	})
+}
+
+// regexpMode parses the token regex mode.
+func (o *DiffOptions) regexpMode() (linediff.RegexpMode, error) {
+	switch o.TokenRegexMode {
+	case "", "match":
+		return linediff.RegexpMatch, nil
+	case "split":
+		return linediff.RegexpSplit, nil
+	default:
+		return 0, fmt.Errorf("unknown token regex mode '%s', expected 'match' or 'split'", o.TokenRegexMode)
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"github.com/drognisep/linediff"
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// parseTestOptions parses the command line into DiffOptions, starting from the flag defaults.
+func parseTestOptions(t *testing.T, cmdline ...string) *DiffOptions {
+	t.Helper()
+	var (
+		o     = new(DiffOptions)
+		flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	)
+	o.AddFlags(flags)
+	require.NoError(t, flags.Parse(cmdline))
+	return o
+}
+
+func TestDiffOptions_Escape(t *testing.T) {
+	tests := map[string]struct {
+		cmdline []string
+		input   string
+		tokens  []string
+	}{
+		"Backslash by default": {
+			cmdline: []string{`--quotes="`},
+			input:   `a "b \" c" d`,
+			tokens:  []string{"a", " ", `"b \" c"`, " ", "d"},
+		},
+		"Custom escape": {
+			cmdline: []string{`--quotes="`, "--escape=^"},
+			input:   `a "b ^" c" d`,
+			tokens:  []string{"a", " ", `"b ^" c"`, " ", "d"},
+		},
+		"Doubled quotes": {
+			cmdline: []string{`--quotes='"`, "--escape="},
+			input:   `a 'it''s b' "c \" d`,
+			tokens:  []string{"a", " ", `'it''s b'`, " ", `"c \"`, " ", "d"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := parseTestOptions(t, tc.cmdline...)
+			require.NoError(t, o.Validate())
+			assert.Equal(t, tc.tokens, o.Splitter().Split(linediff.NewStringTokenReader(tc.input)))
+		})
+	}
+
+	assert.Error(t, parseTestOptions(t, "--escape=ab").Validate(), "Escape should be a single rune")
+}
+
+func TestDiffOptions_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cmdline []string
+		valid   bool
+	}{
+		"Defaults":            {valid: true},
+		"Small buffer":        {cmdline: []string{"--buffer=255"}},
+		"Minimum buffer":      {cmdline: []string{"--buffer=256"}, valid: true},
+		"Small lookahead":     {cmdline: []string{"--matchahead=2"}},
+		"One splitter":        {cmdline: []string{"--words"}, valid: true},
+		"Two splitters":       {cmdline: []string{"--words", "--lines"}},
+		"Regex and lang":      {cmdline: []string{"--token-regex=\\w+", "--lang=go"}},
+		"Known language":      {cmdline: []string{"--lang=sql"}, valid: true},
+		"Unknown language":    {cmdline: []string{"--lang=cobol"}},
+		"Split mode":          {cmdline: []string{"--token-regex=,", "--token-regex-mode=split"}, valid: true},
+		"Unknown regex mode":  {cmdline: []string{"--token-regex=,", "--token-regex-mode=between"}},
+		"Invalid regex":       {cmdline: []string{"--token-regex=("}},
+		"Multi-byte escape":   {cmdline: []string{"--escape=¬"}, valid: true},
+		"Multi-rune escape":   {cmdline: []string{"--escape=ab"}},
+		"Doubled quotes only": {cmdline: []string{"--quotes='", "--escape="}, valid: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := parseTestOptions(t, tc.cmdline...).Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestDiffOptions_Splitter(t *testing.T) {
+	tests := map[string]struct {
+		cmdline []string
+		input   string
+		tokens  []string
+	}{
+		"Default delimiter": {
+			input:  "a b  c",
+			tokens: []string{"a", " ", "b", " ", " ", "c"},
+		},
+		"Custom delimiters": {
+			cmdline: []string{"--delim=,;"},
+			input:   "a,b;c d",
+			tokens:  []string{"a", ",", "b", ";", "c d"},
+		},
+		"Words": {
+			cmdline: []string{"--words"},
+			input:   "Hi, you.",
+			tokens:  []string{"Hi", ",", " ", "you", "."},
+		},
+		"Chars": {
+			cmdline: []string{"--chars"},
+			input:   "ab",
+			tokens:  []string{"a", "b"},
+		},
+		"Lines": {
+			cmdline: []string{"--lines"},
+			input:   "a\r\nb\nc",
+			tokens:  []string{"a\r\n", "b\n", "c"},
+		},
+		"Regex matches": {
+			cmdline: []string{"--token-regex=\\d+"},
+			input:   "a1b22",
+			tokens:  []string{"1", "22"},
+		},
+		"Regex separators": {
+			cmdline: []string{"--token-regex=,", "--token-regex-mode=split"},
+			input:   "a,b",
+			tokens:  []string{"a", ",", "b"},
+		},
+		"Language": {
+			cmdline: []string{"--lang=sql"},
+			input:   "x = 'a b'",
+			tokens:  []string{"x", " ", "=", " ", "'a b'"},
+		},
+		"Quotes": {
+			cmdline: []string{`--quotes="`},
+			input:   `a "b c"`,
+			tokens:  []string{"a", " ", `"b c"`},
+		},
+		"Quotes with words": {
+			cmdline: []string{`--quotes="`, "--words"},
+			input:   `a "b, c".`,
+			tokens:  []string{"a", " ", `"b, c"`, "."},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := parseTestOptions(t, tc.cmdline...)
+			require.NoError(t, o.Validate())
+			assert.Equal(t, tc.tokens, o.Splitter().Split(linediff.NewStringTokenReader(tc.input)))
+		})
+	}
+}
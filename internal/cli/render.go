@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"html"
+	"strings"
+)
+
+// RenderFormats are the formats accepted by Render.
+var RenderFormats = []string{"word", "markers", "html", "json"}
+
+// Render renders a DiffSet in one of the RenderFormats.
+//
+//	word     git style word diff, like [-removed-]{+added+}
+//	markers  the DiffSet string notation, like (--removed--)(++added++)
+//	html     escaped segments wrapped in spans with the 'add' or 'rem' class
+//	json     an array of tagged segments, which can be applied as a patch
+func Render(ds *linediff.DiffSet, format string) (string, error) {
+	switch format {
+	case "word":
+		return ds.WordDiff(), nil
+	case "markers":
+		return ds.String(), nil
+	case "html":
+		var buf strings.Builder
+		for _, seg := range ds.Segments() {
+			buf.WriteString(SegmentHTML(seg))
+		}
+		return buf.String(), nil
+	case "json":
+		data, err := json.Marshal(ds)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unknown render format '%s', expected one of %s", format, strings.Join(RenderFormats, ", "))
+	}
+}
+
+// SegmentHTML renders a single segment, wrapping changes in a span with the 'add' or 'rem' class.
+// The segment text is escaped, so the result is safe to embed in an HTML document.
+func SegmentHTML(seg linediff.Segment) string {
+	text := html.EscapeString(seg.Text)
+	switch seg.Tag {
+	case linediff.Removed:
+		return fmt.Sprintf(`<span class="rem">%s</span>`, text)
+	case linediff.Added:
+		return fmt.Sprintf(`<span class="add">%s</span>`, text)
+	default:
+		return text
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"github.com/drognisep/linediff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	ds := linediff.Diff("a <b> c", "a <d> c")
+	tests := map[string]string{
+		"word":    "a [-<b>-]{+<d>+} c",
+		"markers": "a (--<b>--)(++<d>++) c",
+		"html":    `a <span class="rem">&lt;b&gt;</span><span class="add">&lt;d&gt;</span> c`,
+	}
+
+	for format, expected := range tests {
+		t.Run(format, func(t *testing.T) {
+			output, err := Render(ds, format)
+			require.NoError(t, err)
+			assert.Equal(t, expected, output)
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		output, err := Render(ds, "json")
+		require.NoError(t, err)
+		parsed := new(linediff.DiffSet)
+		require.NoError(t, json.Unmarshal([]byte(output), parsed))
+		assert.Equal(t, ds.Segments(), parsed.Segments())
+	})
+
+	_, err := Render(ds, "unified")
+	assert.Error(t, err, "Unknown formats should be an error")
+}
+
+func TestSegmentHTML(t *testing.T) {
+	tests := map[string]struct {
+		seg      linediff.Segment
+		expected string
+	}{
+		"Same":    {seg: linediff.Segment{Tag: linediff.Same, Text: "a & b"}, expected: "a &amp; b"},
+		"Added":   {seg: linediff.Segment{Tag: linediff.Added, Text: "<i>"}, expected: `<span class="add">&lt;i&gt;</span>`},
+		"Removed": {seg: linediff.Segment{Tag: linediff.Removed, Text: `"q"`}, expected: `<span class="rem">&#34;q&#34;</span>`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SegmentHTML(tc.seg))
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// envPrefix is prepended to upper case flag names, with dashes replaced by underscores, to find environment variables.
+const envPrefix = "DIFFHTML_"
+
+// argsSetting is the config file setting for positional arguments, like the files to compare.
+const argsSetting = "args"
+
+// metaFlags control how the configuration is loaded, so they can't be set in a config file or environment variable.
+var metaFlags = map[string]bool{
+	"help":         true,
+	"config":       true,
+	"print-config": true,
+	"job":          true,
+}
+
+// settingsFile is a YAML config file, with settings named after flags.
+// Shared settings apply to every job, and each job may override them.
+type settingsFile struct {
+	Settings map[string]any `yaml:",inline"`
+	Jobs     []jobSettings  `yaml:"jobs,omitempty"`
+}
+
+// jobSettings are the settings for a single named report.
+type jobSettings struct {
+	Name     string         `yaml:"name,omitempty"`
+	Settings map[string]any `yaml:",inline"`
+}
+
+// envName returns the environment variable name for a flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// loadSettingsFile reads and validates a config file.
+func loadSettingsFile(path string) (*settingsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+	file := new(settingsFile)
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse config file '%s': %s", ErrInvalidConfig, path, err)
+	}
+
+	flags := setupFlags("", new(Config))
+	seen := map[string]bool{}
+	check := func(settings map[string]any) error {
+		for name := range settings {
+			if name == argsSetting {
+				continue
+			}
+			if metaFlags[name] {
+				return fmt.Errorf("%w: '%s' can't be set in config file '%s'", ErrInvalidConfig, name, path)
+			}
+			if flags.Lookup(name) == nil {
+				return fmt.Errorf("%w: unknown setting '%s' in config file '%s'", ErrInvalidConfig, name, path)
+			}
+		}
+		return nil
+	}
+	if err := check(file.Settings); err != nil {
+		return nil, err
+	}
+	for i, job := range file.Jobs {
+		if len(job.Name) == 0 {
+			return nil, fmt.Errorf("%w: job %d in config file '%s' has no name", ErrInvalidConfig, i+1, path)
+		}
+		if seen[job.Name] {
+			return nil, fmt.Errorf("%w: job '%s' is defined more than once in config file '%s'", ErrInvalidConfig, job.Name, path)
+		}
+		seen[job.Name] = true
+		if err := check(job.Settings); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// selectJobs returns the named jobs, or every job if no names are given.
+// If there's no config file, or it doesn't define jobs, then a single unnamed job is returned.
+func (f *settingsFile) selectJobs(names []string) ([]jobSettings, error) {
+	if f == nil || len(f.Jobs) == 0 {
+		if len(names) > 0 {
+			return nil, fmt.Errorf("%w: no jobs are defined to select '%s'", ErrInvalidConfig, strings.Join(names, ", "))
+		}
+		return []jobSettings{{}}, nil
+	}
+	if len(names) == 0 {
+		return f.Jobs, nil
+	}
+	var jobs []jobSettings
+	for _, name := range names {
+		found := false
+		for _, job := range f.Jobs {
+			if job.Name == name {
+				jobs = append(jobs, job)
+				found = true
+				break
+			}
+		}
+		if !found {
+			available := make([]string, len(f.Jobs))
+			for i, job := range f.Jobs {
+				available[i] = job.Name
+			}
+			return nil, fmt.Errorf("%w: job '%s' is not one of %s", ErrInvalidConfig, name, quoteList(available))
+		}
+	}
+	return jobs, nil
+}
+
+// settingValues converts a config file setting to the values that are passed to the flag.
+// Lists set the flag once per element, which appends to repeatable flags like 'pair'.
+func settingValues(name string, value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			switch elem.(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("%w: setting '%s' can't have nested values", ErrInvalidConfig, name)
+			}
+			values = append(values, fmt.Sprint(elem))
+		}
+		return values, nil
+	case map[string]any:
+		return nil, fmt.Errorf("%w: setting '%s' can't be a mapping", ErrInvalidConfig, name)
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// loadConfig parses the command line for a job, with settings layered as defaults, then the config file, then environment variables, then flags.
+// The positional arguments from the command line are returned, or the 'args' setting if there are none.
+func loadConfig(name string, cmdline []string, file *settingsFile, job jobSettings) (*Config, *flag.FlagSet, []string, error) {
+	config := new(Config)
+	flags := setupFlags(name, config)
+	if err := flags.Parse(cmdline); err != nil {
+		return nil, nil, nil, err
+	}
+
+	layered := map[string][]string{}
+	var args []string
+	if file != nil {
+		for _, settings := range []map[string]any{file.Settings, job.Settings} {
+			for name, value := range settings {
+				values, err := settingValues(name, value)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if name == argsSetting {
+					args = values
+					continue
+				}
+				layered[name] = values
+			}
+		}
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		if metaFlags[f.Name] {
+			return
+		}
+		if value, ok := os.LookupEnv(envName(f.Name)); ok {
+			layered[f.Name] = []string{value}
+		}
+	})
+
+	for name, values := range layered {
+		if flags.Changed(name) {
+			continue
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return nil, nil, nil, fmt.Errorf("%w: invalid value '%s' for setting '%s': %s", ErrInvalidConfig, value, name, err)
+			}
+		}
+	}
+	if flags.NArg() > 0 {
+		args = flags.Args()
+	}
+	return config, flags, args, nil
+}
+
+// effectiveSettings returns the value of every setting, which can be written back out as a config file.
+func effectiveSettings(name string, flags *flag.FlagSet, args []string) map[string]any {
+	settings := map[string]any{}
+	if len(name) > 0 {
+		settings["name"] = name
+	}
+	if len(args) > 0 {
+		settings[argsSetting] = args
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		if metaFlags[f.Name] {
+			return
+		}
+		if slice, ok := f.Value.(flag.SliceValue); ok {
+			settings[f.Name] = slice.GetSlice()
+			return
+		}
+		value := f.Value.String()
+		switch f.Value.Type() {
+		case "bool":
+			settings[f.Name], _ = strconv.ParseBool(value)
+		case "int":
+			settings[f.Name], _ = strconv.Atoi(value)
+		case "float64":
+			settings[f.Name], _ = strconv.ParseFloat(value, 64)
+		default:
+			settings[f.Name] = value
+		}
+	})
+	return settings
+}
+
+// printConfig writes the effective settings of each job as YAML.
+// A single unnamed job is written as shared settings, and named jobs are written as a list of jobs.
+func printConfig(w io.Writer, jobs []map[string]any) error {
+	var doc any = map[string]any{"jobs": jobs}
+	if len(jobs) == 1 && jobs[0]["name"] == nil {
+		doc = jobs[0]
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestFile(t, "diffhtml.yaml", `
+header-a: File A
+header-b: File B
+workers: 2
+pair: ["0:1"]
+args: [a.txt, b.txt]
+jobs:
+  - name: orders
+    header-b: Job B
+    workers: 3
+    pair: ["1:2", "1:3"]
+`)
+	file, err := loadSettingsFile(path)
+	require.NoError(t, err)
+	job := file.Jobs[0]
+
+	tests := map[string]struct {
+		cmdline []string
+		env     map[string]string
+		file    *settingsFile
+		aLabel  string
+		bLabel  string
+		workers int
+		pairs   []string
+		args    []string
+	}{
+		"Defaults": {
+			aLabel:  "A",
+			bLabel:  "B",
+			workers: 1,
+		},
+		"Config file job overrides shared settings": {
+			file:    file,
+			aLabel:  "File A",
+			bLabel:  "Job B",
+			workers: 3,
+			pairs:   []string{"1:2", "1:3"},
+			args:    []string{"a.txt", "b.txt"},
+		},
+		"Environment overrides config file": {
+			file:    file,
+			env:     map[string]string{"DIFFHTML_HEADER_A": "Env A", "DIFFHTML_WORKERS": "4"},
+			aLabel:  "Env A",
+			bLabel:  "Job B",
+			workers: 4,
+			pairs:   []string{"1:2", "1:3"},
+			args:    []string{"a.txt", "b.txt"},
+		},
+		"Flags override environment": {
+			cmdline: []string{"--header-a", "Flag A", "--pair", "0:2", "c.txt", "d.txt"},
+			file:    file,
+			env:     map[string]string{"DIFFHTML_HEADER_A": "Env A", "DIFFHTML_WORKERS": "4"},
+			aLabel:  "Flag A",
+			bLabel:  "Job B",
+			workers: 4,
+			pairs:   []string{"0:2"},
+			args:    []string{"c.txt", "d.txt"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			config, _, args, err := loadConfig("diffhtml", tc.cmdline, tc.file, job)
+			require.NoError(t, err)
+			assert.Equal(t, tc.aLabel, config.ALabel)
+			assert.Equal(t, tc.bLabel, config.BLabel)
+			assert.Equal(t, tc.workers, config.Workers)
+			assert.Equal(t, tc.pairs, config.Pairs)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestLoadConfig_InvalidSetting(t *testing.T) {
+	t.Setenv("DIFFHTML_WORKERS", "many")
+	_, _, _, err := loadConfig("diffhtml", nil, nil, jobSettings{})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
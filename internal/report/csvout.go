@@ -0,0 +1,129 @@
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// annotationHeaders are appended to the input header when writing annotated CSV output.
+var annotationHeaders = []string{"diff", "tokens_added", "tokens_removed", "similarity", "changed"}
+
+// csvReportWriter writes each input record back out with diff annotation columns appended.
+// Records are written exactly as they were read, including their quoting and line endings, and the annotation columns are
+// appended before each line ending with the input delimiter.
+// With more than one comparison, annotation columns are written for each comparison, and labels are used to tell them apart.
+type csvReportWriter struct {
+	out         io.WriteCloser
+	bufw        *bufio.Writer
+	input       *delimitedReader
+	labels      []string
+	wroteHeader bool
+}
+
+func newCSVReportWriter(input *delimitedReader, outFile string, labels []string) (*csvReportWriter, error) {
+	out, err := createOutput(outFile)
+	if err != nil {
+		return nil, err
+	}
+	return &csvReportWriter{
+		out:    out,
+		bufw:   bufio.NewWriter(out),
+		input:  input,
+		labels: labels,
+	}, nil
+}
+
+// writeHeader writes the extended header row, if the input has one.
+// The header is only known after the first read of the input.
+func (w *csvReportWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	if w.input.header == nil {
+		return nil
+	}
+	var header []string
+	if len(w.labels) == 0 {
+		header = append(header, annotationHeaders...)
+	}
+	for _, label := range w.labels {
+		for _, name := range annotationHeaders {
+			header = append(header, fmt.Sprintf("%s (%s)", name, label))
+		}
+	}
+	if err := w.writeRow(w.input.rawHeader, header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+func (w *csvReportWriter) WriteRecord(r *diffRecord) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var annotations []string
+	for _, c := range r.Comparisons {
+		ds := c.Diff()
+		stats := ds.Stats()
+		annotations = append(annotations,
+			ds.WordDiff(),
+			strconv.Itoa(stats.Added),
+			strconv.Itoa(stats.Removed),
+			strconv.FormatFloat(stats.Similarity, 'f', 4, 64),
+			strings.ToUpper(strconv.FormatBool(stats.Changed())),
+		)
+	}
+	if err := w.writeRow(r.raw, annotations); err != nil {
+		return fmt.Errorf("failed to write CSV record: %w", err)
+	}
+	return nil
+}
+
+// writeRow writes the raw text of a record with the annotation fields appended before its line ending.
+// Annotations are quoted where needed in CSV output, and written as they are in TSV output, which has no quoting.
+func (w *csvReportWriter) writeRow(raw string, annotations []string) error {
+	text, eol := cutLineEnding(raw)
+	var fields string
+	if w.input.comma == '\t' {
+		fields = strings.Join(annotations, "\t")
+	} else {
+		var buf strings.Builder
+		csvw := csv.NewWriter(&buf)
+		csvw.UseCRLF = eol == "\r\n"
+		if err := csvw.Write(annotations); err != nil {
+			return err
+		}
+		csvw.Flush()
+		fields, _ = cutLineEnding(buf.String())
+	}
+	_, err := w.bufw.WriteString(text + string(w.input.comma) + fields + eol)
+	return err
+}
+
+// cutLineEnding splits the line ending from the end of a record's text, if it has one.
+func cutLineEnding(text string) (string, string) {
+	for _, eol := range []string{"\r\n", "\n"} {
+		if before, found := strings.CutSuffix(text, eol); found {
+			return before, eol
+		}
+	}
+	return text, ""
+}
+
+func (w *csvReportWriter) Close(_ *reportSummary) error {
+	err := w.writeHeader()
+	if err == nil {
+		if err = w.bufw.Flush(); err != nil {
+			err = fmt.Errorf("failed to write CSV file: %w", err)
+		}
+	}
+	if closeErr := w.out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
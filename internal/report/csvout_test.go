@@ -0,0 +1,112 @@
+package report
+
+import (
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runTestReport parses the command line like diffhtml, and runs the report it describes.
+func runTestReport(t *testing.T, cmdline ...string) (*reportSummary, error) {
+	t.Helper()
+	config := new(Config)
+	flags := setupFlags("diffhtml", config)
+	require.NoError(t, flags.Parse(cmdline))
+	return run(config, flags, flags.Args())
+}
+
+// writeTestFile writes a file in a temporary directory, and returns its path.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestCSVReportWriter_RoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		// records holds the text of each input record, which should be written back out unchanged.
+		records []string
+		tsv     bool
+		crlf    bool
+	}{
+		"LF": {
+			records: []string{"id,a,b\n", "1,x y,x z\n", "2,\"a, b\",\"a, c\"\n"},
+		},
+		"CRLF": {
+			records: []string{"id,a,b\r\n", "1,x y,x z\r\n", "2,\"a, b\",\"a, c\"\r\n"},
+			crlf:    true,
+		},
+		"CRLF with quoted line break": {
+			records: []string{"id,a,b\r\n", "1,\"two\r\nlines\",\"two\r\nrows\"\r\n", "2,\"say \"\"hi\"\"\",say hi\r\n"},
+			crlf:    true,
+		},
+		"Unneeded quotes and no final line break": {
+			records: []string{"\ufeffid,\"a\",\"b\"\n", "\n\"1\",\"x\",\"y\"\n", "\"2\",\"\",\"z\""},
+		},
+		"TSV": {
+			records: []string{"id\ta\tb\n", "1\t\"x\ty\n"},
+			tsv:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := strings.Join(tc.records, "")
+			in := writeTestFile(t, "in.csv", input)
+			out := filepath.Join(t.TempDir(), "out.csv")
+			format := FormatCSV
+			if tc.tsv {
+				format = FormatTSV
+			}
+			_, err := runTestReport(t, "--csv", in, "--format", format, "-a", "a", "-b", "b", "--output-format", "csv", "-o", out)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(out)
+			require.NoError(t, err)
+			output := string(data)
+			if tc.crlf {
+				assert.Equal(t, strings.Count(output, "\n"), strings.Count(output, "\r\n"), "All line endings should be CRLF")
+			} else {
+				assert.NotContains(t, output, "\r\n")
+			}
+
+			delim := ","
+			if tc.tsv {
+				delim = "\t"
+			}
+			rest := output
+			for i, record := range tc.records {
+				text, _ := cutLineEnding(record)
+				require.True(t, strings.HasPrefix(rest, text+delim), "Record %d should be written as it was read: %q", i, rest)
+				rest = rest[len(text)+len(delim):]
+				// Skip the annotations, which may be quoted over more than one line.
+				annotations := csv.NewReader(strings.NewReader(rest))
+				annotations.Comma = rune(delim[0])
+				annotations.LazyQuotes = tc.tsv
+				fields, err := annotations.Read()
+				require.NoError(t, err)
+				assert.Len(t, fields, len(annotationHeaders))
+				rest = rest[annotations.InputOffset():]
+			}
+			assert.Empty(t, rest)
+
+			if tc.tsv {
+				return
+			}
+			expected, err := csv.NewReader(strings.NewReader(input)).ReadAll()
+			require.NoError(t, err)
+			actual, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, actual, len(expected))
+			for i, record := range expected {
+				assert.Equal(t, record, actual[i][:len(record)], "Original fields of row %d should be unchanged", i)
+				assert.Len(t, actual[i], len(record)+len(annotationHeaders))
+			}
+		})
+	}
+}
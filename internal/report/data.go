@@ -0,0 +1,107 @@
+package report
+
+import (
+	"github.com/drognisep/linediff"
+	"github.com/drognisep/linediff/internal/cli"
+	"html/template"
+	"strings"
+)
+
+// newDiffer creates a Differ with the configured options.
+func newDiffer(config *Config) *linediff.Differ {
+	return config.Differ()
+}
+
+// comparison is a single diff between two values.
+type comparison struct {
+	A, B string
+	// Label describes the compared columns in reports with more than one comparison.
+	Label  string
+	differ *linediff.Differ
+	diff   *linediff.DiffSet
+}
+
+// Diff returns the diff of A and B, which is only calculated once.
+func (c *comparison) Diff() *linediff.DiffSet {
+	if c.diff == nil {
+		c.diff = c.differ.Diff(c.A, c.B)
+	}
+	return c.diff
+}
+
+func (c *comparison) Stats() linediff.DiffStats {
+	return c.Diff().Stats()
+}
+
+// DiffHTML renders the diff with escaped segments wrapped in spans, so templates can output it without escaping it again.
+func (c *comparison) DiffHTML() template.HTML {
+	var buf strings.Builder
+	for _, seg := range c.Diff().Segments() {
+		buf.WriteString(cli.SegmentHTML(seg))
+	}
+	return template.HTML(buf.String())
+}
+
+// diffRecord is a single row of a report.
+// The first comparison is embedded, so single pair reports can use A, B, Diff, and DiffHTML directly.
+type diffRecord struct {
+	comparison
+	Row          int
+	LineA, LineB int
+	// Status is the status of a table row in a keyed table comparison, and is empty otherwise.
+	Status string
+	// Values holds the value of each selected column.
+	Values []string
+	// Comparisons holds every comparison for the record, starting with the embedded comparison.
+	Comparisons []*comparison
+	// raw holds the text of a CSV or TSV record as it was read, so it can be written back out.
+	raw string
+}
+
+// newPairRecord creates a record comparing a single pair of values.
+func newPairRecord(a, b string, differ *linediff.Differ) *diffRecord {
+	r := &diffRecord{
+		comparison: comparison{A: a, B: b, differ: differ},
+		Values:     []string{a, b},
+	}
+	r.Comparisons = []*comparison{&r.comparison}
+	return r
+}
+
+// newRecord creates a record comparing the selected values as specified.
+func newRecord(values []string, specs []comparisonSpec, differ *linediff.Differ) *diffRecord {
+	r := &diffRecord{Values: values}
+	for _, spec := range specs {
+		r.addComparison(values[spec.A], values[spec.B], spec.Label, differ)
+	}
+	return r
+}
+
+// addComparison adds a comparison to the record, which is embedded if it's the first.
+func (r *diffRecord) addComparison(a, b, label string, differ *linediff.Differ) {
+	c := &r.comparison
+	if len(r.Comparisons) > 0 {
+		c = new(comparison)
+	}
+	c.A, c.B, c.Label, c.differ = a, b, label, differ
+	r.Comparisons = append(r.Comparisons, c)
+}
+
+// diffAll calculates the diff of every comparison.
+func (r *diffRecord) diffAll() {
+	for _, c := range r.Comparisons {
+		c.Diff()
+	}
+}
+
+// Stats returns the combined statistics of all comparisons in the record.
+func (r *diffRecord) Stats() linediff.DiffStats {
+	if len(r.Comparisons) <= 1 {
+		return r.comparison.Stats()
+	}
+	stats := make([]linediff.DiffStats, len(r.Comparisons))
+	for i, c := range r.Comparisons {
+		stats[i] = c.Stats()
+	}
+	return linediff.CombineStats(stats...)
+}
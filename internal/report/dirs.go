@@ -0,0 +1,198 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+	StatusChanged   = "changed"
+	StatusIdentical = "identical"
+)
+
+var (
+	//go:embed index.gohtml
+	indexTemplText string
+	indexTempl     = template.Must(template.New("index").Parse(indexTemplText))
+)
+
+// dirFileResult summarizes the comparison of a single file path between two directory trees.
+type dirFileResult struct {
+	Path       string
+	Status     string
+	Changes    int
+	DetailPage string
+}
+
+// listFiles returns the slash separated paths of all regular files within root, relative to root.
+func listFiles(root string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory '%s': %w", root, err)
+	}
+	return files, nil
+}
+
+// countChanges returns the number of aligned line records that are not identical.
+func countChanges(records []*diffRecord) int {
+	var changes int
+	for _, r := range records {
+		if r.LineA == 0 || r.LineB == 0 || r.A != r.B {
+			changes++
+		}
+	}
+	return changes
+}
+
+// runDirGeneration compares two directory trees file by file, generating an index page and a detail page per file in the output directory.
+// The returned summary covers the lines of every file.
+func runDirGeneration(config *Config, oldDir, newDir string) (*reportSummary, error) {
+	log.Println("Reading directories...")
+	oldFiles, err := listFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := listFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for p := range oldFiles {
+		paths = append(paths, p)
+	}
+	for p := range newFiles {
+		if !oldFiles[p] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	if err := os.MkdirAll(config.OutDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory '%s': %w", config.OutDir, err)
+	}
+
+	log.Println("Comparing files...")
+	var (
+		differ  = newDiffer(config)
+		results = make([]dirFileResult, 0, len(paths))
+		counts  = map[string]int{}
+		total   = newReportSummary()
+	)
+	for _, p := range paths {
+		var (
+			result   = dirFileResult{Path: p}
+			oldLines []string
+			newLines []string
+		)
+		if oldFiles[p] {
+			oldLines, err = readLines(filepath.Join(oldDir, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if newFiles[p] {
+			newLines, err = readLines(filepath.Join(newDir, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		records := alignFileLines(oldLines, newLines, differ)
+		result.Changes = countChanges(records)
+		switch {
+		case !newFiles[p]:
+			result.Status = StatusRemoved
+		case !oldFiles[p]:
+			result.Status = StatusAdded
+		case result.Changes > 0:
+			result.Status = StatusChanged
+		default:
+			result.Status = StatusIdentical
+		}
+		counts[result.Status]++
+
+		if result.Status != StatusIdentical {
+			result.DetailPage = path.Join("files", p+".html")
+			summary, err := writeDetailPage(config, result, records)
+			if err != nil {
+				return nil, err
+			}
+			total.merge(summary)
+		} else {
+			for _, r := range records {
+				total.AddRecord(r)
+			}
+		}
+		results = append(results, result)
+	}
+
+	log.Println("Generating index...")
+	indexFile := filepath.Join(config.OutDir, "index.html")
+	var buf bytes.Buffer
+	err = indexTempl.Execute(&buf, map[string]any{
+		"OldDir":  oldDir,
+		"NewDir":  newDir,
+		"Results": results,
+		"Counts":  counts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate index file: %w", err)
+	}
+	if err := os.WriteFile(indexFile, buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write index file '%s': %w", indexFile, err)
+	}
+	log.Println("Done")
+	return total, nil
+}
+
+// writeDetailPage generates the line diff report for a single file in the directory comparison.
+func writeDetailPage(config *Config, result dirFileResult, records []*diffRecord) (*reportSummary, error) {
+	outFile := filepath.Join(config.OutDir, filepath.FromSlash(result.DetailPage))
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory for '%s': %w", outFile, err)
+	}
+
+	depth := strings.Count(result.DetailPage, "/")
+	w, err := newHTMLReportWriter(reportPage{
+		FileName:    result.Path,
+		HeaderA:     config.ALabel,
+		HeaderB:     config.BLabel,
+		Columns:     []string{config.ALabel, config.BLabel},
+		Comparisons: []string{"Difference"},
+		LineNumbers: true,
+		IndexLink:   strings.Repeat("../", depth) + "index.html",
+	}, outFile, config.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := generateReport(config, w, sliceSource(records))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report for '%s': %w", result.Path, err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"fmt"
+)
+
+const (
+	// ExitIdentical is the exit code when no differences are found, or all thresholds are met.
+	ExitIdentical = 0
+	// ExitDifferent is the exit code when differences are found, or a threshold is exceeded.
+	ExitDifferent = 1
+	// ExitError is the exit code for errors when exit codes report differences.
+	ExitError = 2
+)
+
+// gating returns true if the exit code should report differences or threshold failures, like diff.
+func (c *Config) gating() bool {
+	return c.ExitCode || c.MinSimilarity > 0 || c.MaxChanged >= 0
+}
+
+// errorCode returns the exit code for errors.
+// Errors are reported with 1 by default, and with 2 when the exit code reports differences, so the two can be told apart.
+func (c *Config) errorCode() int {
+	if c.gating() {
+		return ExitError
+	}
+	return 1
+}
+
+// checkSummary determines the exit code for a completed comparison, and the reason for a non-zero exit code.
+// With 'exit-code', any changed record is a difference. The 'min-similarity' and 'max-changed' thresholds
+// are checked independently, so differences within the thresholds still exit with 0 unless 'exit-code' is set.
+func (c *Config) checkSummary(summary *reportSummary) (int, string) {
+	if !c.gating() {
+		return ExitIdentical, ""
+	}
+	switch {
+	case c.MinSimilarity > 0 && summary.MinSimilarity < c.MinSimilarity:
+		return ExitDifferent, fmt.Sprintf("Lowest similarity %.1f%% is below the minimum of %.1f%%", summary.MinSimilarity*100, c.MinSimilarity*100)
+	case c.MaxChanged >= 0 && summary.Changed > c.MaxChanged:
+		return ExitDifferent, fmt.Sprintf("%d records changed, which is more than the maximum of %d", summary.Changed, c.MaxChanged)
+	case c.ExitCode && summary.Changed > 0:
+		return ExitDifferent, fmt.Sprintf("%d records changed", summary.Changed)
+	default:
+		return ExitIdentical, ""
+	}
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfig_CheckSummary(t *testing.T) {
+	tests := map[string]struct {
+		config  Config
+		summary reportSummary
+		code    int
+		reason  string
+	}{
+		"Not gating": {
+			config:  Config{MaxChanged: -1},
+			summary: reportSummary{Changed: 3, MinSimilarity: 0.1},
+			code:    ExitIdentical,
+		},
+		"Exit code without changes": {
+			config:  Config{ExitCode: true, MaxChanged: -1},
+			summary: reportSummary{MinSimilarity: 1},
+			code:    ExitIdentical,
+		},
+		"Exit code with changes": {
+			config:  Config{ExitCode: true, MaxChanged: -1},
+			summary: reportSummary{Changed: 2, MinSimilarity: 0.5},
+			code:    ExitDifferent,
+			reason:  "2 records changed",
+		},
+		"Below minimum similarity": {
+			config:  Config{MinSimilarity: 0.8, MaxChanged: -1},
+			summary: reportSummary{Changed: 1, MinSimilarity: 0.5},
+			code:    ExitDifferent,
+			reason:  "Lowest similarity 50.0% is below the minimum of 80.0%",
+		},
+		"Within minimum similarity": {
+			config:  Config{MinSimilarity: 0.5, MaxChanged: -1},
+			summary: reportSummary{Changed: 1, MinSimilarity: 0.5},
+			code:    ExitIdentical,
+		},
+		"Above maximum changed": {
+			config:  Config{MaxChanged: 1},
+			summary: reportSummary{Changed: 2, MinSimilarity: 0.5},
+			code:    ExitDifferent,
+			reason:  "2 records changed, which is more than the maximum of 1",
+		},
+		"Within maximum changed": {
+			config:  Config{MaxChanged: 2},
+			summary: reportSummary{Changed: 2, MinSimilarity: 0.5},
+			code:    ExitIdentical,
+		},
+		"Exit code within thresholds": {
+			config:  Config{ExitCode: true, MaxChanged: 2},
+			summary: reportSummary{Changed: 1, MinSimilarity: 0.5},
+			code:    ExitDifferent,
+			reason:  "1 records changed",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, reason := tc.config.checkSummary(&tc.summary)
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestConfig_ErrorCode(t *testing.T) {
+	assert.Equal(t, 1, (&Config{MaxChanged: -1}).errorCode())
+	assert.Equal(t, ExitError, (&Config{ExitCode: true, MaxChanged: -1}).errorCode())
+	assert.Equal(t, ExitError, (&Config{MaxChanged: 0}).errorCode())
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+var ErrInvalidColIndex = errors.New("invalid column index")
+
+// createOutput creates the named output file, or uses STDOUT if the name is "-".
+func createOutput(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file '%s': %w", name, err)
+	}
+	return out, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func runFileGeneration(config *Config) (*reportSummary, error) {
+	sel, err := selectColumns(config)
+	if err != nil {
+		return nil, err
+	}
+	in, err := openInput(config.InFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	records, err := newRecordReader(config, in, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := config.InFile
+	if fileName == "-" {
+		fileName = "STDIN"
+	}
+	page := reportPage{
+		FileName:    fileName,
+		HeaderA:     config.ALabel,
+		HeaderB:     config.BLabel,
+		Columns:     []string{config.ALabel, config.BLabel},
+		Comparisons: []string{"Difference"},
+	}
+	multiple := len(config.Pairs) > 0 || len(config.Baseline) > 0
+	if delimited, ok := records.(*delimitedReader); ok && delimited.header != nil && !multiple {
+		labels := delimited.Labels()
+		if !config.ALabelSet {
+			page.HeaderA = labels[0]
+		}
+		if !config.BLabelSet {
+			page.HeaderB = labels[1]
+		}
+		page.Columns = []string{page.HeaderA, page.HeaderB}
+	}
+	if multiple {
+		page.Columns = records.Labels()
+		page.Comparisons = nil
+		for i, spec := range sel.specs {
+			sel.specs[i].Label = fmt.Sprintf("%s → %s", page.Columns[spec.A], page.Columns[spec.B])
+			page.Comparisons = append(page.Comparisons, sel.specs[i].Label)
+		}
+		page.HeaderA, page.HeaderB = page.Columns[sel.specs[0].A], page.Columns[sel.specs[0].B]
+	}
+
+	var w reportWriter
+	if config.OutputFormat == OutputCSV {
+		delimited, ok := records.(*delimitedReader)
+		if !ok {
+			return nil, fmt.Errorf("%w: CSV output requires CSV or TSV input", ErrInvalidOutputFormat)
+		}
+		var labels []string
+		if multiple {
+			labels = page.Comparisons
+		}
+		w, err = newCSVReportWriter(delimited, config.OutFile, labels)
+	} else {
+		w, err = newReportWriter(config, page, config.OutFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Generating report...")
+	var (
+		differ = newDiffer(config)
+		row    int
+	)
+	summary, err := generateReport(config, w, func() (*diffRecord, error) {
+		values, err := records.Read()
+		if err != nil {
+			return nil, err
+		}
+		row++
+		record := newRecord(values, sel.specs, differ)
+		record.Row = row
+		if delimited, ok := records.(*delimitedReader); ok {
+			record.raw = delimited.raw
+		}
+		return record, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Done")
+	return summary, nil
+}
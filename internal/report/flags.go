@@ -0,0 +1,318 @@
+package report
+
+import (
+	"fmt"
+	"github.com/drognisep/linediff/internal/cli"
+	flag "github.com/spf13/pflag"
+	"strings"
+)
+
+type Config struct {
+	cli.DiffOptions
+	HelpRequested bool
+	ConfigFile    string
+	PrintConfig   bool
+	Jobs          []string
+	InFile        string
+	LineFiles     bool
+	Dirs          bool
+	Tables        bool
+	Key           []string
+	RowSimilarity float64
+	JSONCells     bool
+	OutDir        string
+	Format        string
+	ACol          string
+	ALabel        string
+	BCol          string
+	BLabel        string
+	AField        string
+	BField        string
+	Pairs         []string
+	Baseline      string
+	Compare       []string
+	OutFile       string
+	OutputFormat  string
+	SkipFirstRow  bool
+	ChangedOnly   bool
+	PageSize      int
+	Workers       int
+	Template      string
+	ExitCode      bool
+	MinSimilarity float64
+	MaxChanged    int
+	// ALabelSet and BLabelSet are true if the labels were given explicitly, rather than taken from a header row.
+	ALabelSet bool
+	BLabelSet bool
+}
+
+// FlagSet returns the report flags, so other commands can describe them, like in shell completions.
+func FlagSet(name string) *flag.FlagSet {
+	return setupFlags(name, new(Config))
+}
+
+// setupFlags creates the flag set for a command with the given name, like 'diffhtml' or 'linediff report'.
+func setupFlags(name string, config *Config) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.BoolVarP(&config.HelpRequested, "help", "h", false, "Prints this usage information.")
+	flags.StringVar(&config.ConfigFile, "config", "", "Loads settings and named report jobs from a YAML config file. See CONFIG FILES below.")
+	flags.BoolVar(&config.PrintConfig, "print-config", false, "Prints the effective settings of each job as YAML instead of generating reports.")
+	flags.StringSliceVar(&config.Jobs, "job", nil, "Specifies a comma separated list of config file jobs to run, instead of all of them.")
+	config.DiffOptions.AddFlags(flags)
+	flags.StringVar(&config.InFile, "csv", "", "Specifies an input file should be read instead of arguments. Use '-' to read from STDIN. Must be used with 'col-a' and 'col-b', or 'field-a' and 'field-b' for JSON Lines.")
+	flags.StringVar(&config.InFile, "in", "", "Alias for 'csv', for inputs that aren't CSV.")
+	flags.BoolVar(&config.LineFiles, "files", false, "Diffs two text files given as arguments line by line, instead of A and B strings.")
+	flags.BoolVar(&config.Dirs, "dirs", false, "Diffs two directory trees given as arguments file by file, generating an index page and a page per file.")
+	flags.BoolVar(&config.Tables, "tables", false, "Diffs two versions of a CSV or TSV table given as arguments, joining rows by the 'key' columns or aligning them by content.")
+	flags.StringSliceVar(&config.Key, "key", nil, "Specifies a comma separated list of key columns, by 0-indexed position or header name, that identify rows with the 'tables' option.")
+	flags.Float64Var(&config.RowSimilarity, "similarity", 0.5, "Sets the minimum similarity, from 0 to 1, for rows without a 'key' to be paired as changed instead of removed and added.")
+	flags.BoolVar(&config.JSONCells, "json-cells", false, "Compares table cells holding a JSON object or array structurally with the 'tables' option, so key order and formatting aren't changes.")
+	flags.StringVar(&config.OutDir, "out-dir", "diff-report", "Specifies an output directory for generation. Only used when the 'dirs' option is specified.")
+	flags.StringVar(&config.Format, "format", FormatCSV, "Sets the input file format. Must be one of 'csv', 'tsv', or 'jsonl'.")
+	flags.StringVarP(&config.OutFile, "out", "o", "index.html", "Specifies an output file for generation, or '-' for STDOUT. Only used when the 'csv' or 'files' option is specified.")
+	flags.StringVar(&config.OutputFormat, "output-format", OutputHTML, "Sets the output format. Must be one of 'html', 'json', or 'csv'. The 'dirs' option only supports 'html', and 'csv' requires CSV or TSV input.")
+	flags.StringVarP(&config.ACol, "col-a", "a", "", "Specifies the A column for comparison, by 0-indexed position or header name. Only useful with the 'csv' or 'tsv' format.")
+	flags.StringVar(&config.ALabel, "header-a", "A", "Sets a label for sample A header. Defaults to the header text of the A column, if there is a header row.")
+	flags.StringVarP(&config.BCol, "col-b", "b", "", "Specifies the B column for comparison, by 0-indexed position or header name. Only useful with the 'csv' or 'tsv' format.")
+	flags.StringVar(&config.BLabel, "header-b", "B", "Sets a label for sample B header. Defaults to the header text of the B column, if there is a header row.")
+	flags.StringVar(&config.AField, "field-a", "", "Specifies the field path of the A sample, like 'a.b[0].c'. Only useful with the 'jsonl' format.")
+	flags.StringVar(&config.BField, "field-b", "", "Specifies the field path of the B sample, like 'a.b[0].c'. Only useful with the 'jsonl' format.")
+	flags.StringArrayVar(&config.Pairs, "pair", nil, "Adds a comparison between two columns or field paths, like '0:1'. May be repeated to compare several pairs in one report. See MULTIPLE COMPARISONS below.")
+	flags.StringVar(&config.Baseline, "baseline", "", "Specifies a baseline column or field path that is compared against each 'compare' column.")
+	flags.StringSliceVar(&config.Compare, "compare", nil, "Specifies a comma separated list of columns or field paths to compare against the 'baseline'.")
+	flags.BoolVar(&config.SkipFirstRow, "skip-header", true, "Skips the first row of a CSV or TSV file as the header.")
+	flags.StringVar(&config.Template, "template", "", "Loads a custom HTML report template from a file. See CUSTOM TEMPLATES below.")
+	flags.IntVar(&config.Workers, "workers", 1, "Sets the number of records that are diffed concurrently. Output is always written in the original record order.")
+	flags.IntVar(&config.PageSize, "page-size", 0, "Splits HTML output into linked pages of this many records each, named like 'index-2.html'. Output is a single page when this is 0.")
+	flags.BoolVar(&config.ExitCode, "exit-code", false, "Exits with 1 if there are any differences, 2 for errors, and 0 otherwise, like diff. See EXIT CODES below.")
+	flags.Float64Var(&config.MinSimilarity, "min-similarity", 0, "Exits with 1 if any record is less similar than this threshold, from 0 to 1. Disabled when 0.")
+	flags.IntVar(&config.MaxChanged, "max-changed", -1, "Exits with 1 if more than this many records changed. Disabled when negative.")
+	flags.BoolVar(&config.ChangedOnly, "changed-only", false, "Omits records without differences from generated output. Summary statistics still include every record.")
+
+	flags.Usage = func() {
+		usage := fmt.Sprintf(`diffhtml generates an HTML page representing a table of diffs and their inputs.
+
+USAGE
+	diffhtml A B
+	diffhtml --csv=FILE -a 0 -b 1
+	diffhtml --csv=FILE -a expected -b actual
+	diffhtml --in=FILE --format=tsv -a 0 -b 1
+	diffhtml --in=FILE --format=jsonl --field-a=PATH --field-b=PATH
+	diffhtml --csv=FILE --pair 0:1 --pair 2:3
+	diffhtml --csv=FILE --baseline 0 --compare 1,2,3
+	diffhtml --files OLD_FILE NEW_FILE
+	diffhtml --dirs OLD_DIR NEW_DIR --out-dir=DIR
+	diffhtml --tables OLD_FILE NEW_FILE --key=COLUMN
+	diffhtml --tables OLD_FILE NEW_FILE --similarity=0.6
+	diffhtml --config=FILE [--job=NAME]
+
+Be sure to escape long strings appropriately for your terminal when passing strings with spaces to the program.
+
+SINGLE PAIR DIFF
+If the 'csv' option is not used, then the first two arguments are expected to be A and B strings, respectively.
+Argument A is diffed against argument B, and the diff is output to STDOUT with the default representation.
+
+Example:
+A: a simple string
+B: a less simple string
+
+This output will be printed to STDOUT with the inputs above.
+a <span class="add">less</span><span class="add"> </span>simple string
+
+CSV FILE DIFF
+If the 'csv' option is used, then each row (excluding the first, assumed to be a header) will be part of a generated HTML table.
+Both inputs will be shown as received, as well as the final diff view.
+
+Example:
+A: a simple string
+B: a less simple string
+
+This output (within the context of a surrounding table) will be generated to file.
+<tr>
+<td>a simple string</td>
+<td>a less simple string</td>
+<td>a <span class="add">less</span><span class="add"> </span>simple string</td>
+</tr>
+
+TWO FILE DIFF
+If the 'files' option is used, then the first two arguments are expected to be the old and new file names, respectively.
+Lines of the two files are aligned with a line-level diff, and changed lines are paired up to be token diffed.
+Lines that only exist in one file are shown as fully removed or added.
+The generated HTML table includes the line numbers of each line in both files.
+
+DIRECTORY DIFF
+If the 'dirs' option is used, then the first two arguments are expected to be the old and new directory names, respectively.
+Files in both trees are paired by their relative path, and each pair is diffed the same way as the 'files' option.
+An index page listing added, removed, changed, and identical files is written to the 'out-dir' directory,
+along with a detail page for each file that isn't identical.
+
+TABLE DIFF
+If the 'tables' option is used, then the first two arguments are expected to be the old and new CSV or TSV files, respectively.
+Rows are joined by the values of the 'key' columns, which must be unique in each file, and are reported as added, removed,
+changed, or identical. Every column is token diffed cell by cell, and columns are matched by header name, so columns that
+were added, removed, or reordered are reported as schema changes instead of changing every row. Columns that only exist in
+one table are labeled '(added)' or '(removed)', and shown as-is for rows in both tables. Without a header row ('skip-header=false'),
+columns are matched by position. Table comparison supports HTML and JSON output.
+
+Without a 'key', rows are aligned by content instead. A sequence diff over whole rows matches identical rows, and rows
+in between are paired as changed if the token diff of their cells is at least 'similarity' similar, or are otherwise
+reported as removed and added. This keeps rows aligned after insertions and deletions, but the cost grows with the
+product of the number of rows in each changed region.
+
+With 'json-cells', cells holding a JSON object or array are rewritten with sorted keys and uniform spacing before rows
+are joined or aligned, and matched cells are compared structurally like 'linediff json', so numbers written differently
+with the same value are equal too. Changed JSON cells are token diffed in their rewritten form.
+
+Example:
+diffhtml --tables customers-old.csv customers-new.csv --key=id -o customers.html
+diffhtml --tables export-old.csv export-new.csv --similarity=0.6 -o export.html
+diffhtml --tables events-old.csv events-new.csv --key=id --json-cells -o events.html
+
+MULTIPLE COMPARISONS
+Instead of 'col-a' and 'col-b' (or 'field-a' and 'field-b'), a report can include several comparisons per record.
+Each 'pair' option adds a comparison of two columns, given as 'A:B' with column indexes or JSON Lines field paths.
+Alternatively, 'baseline' names one column that is compared against each column listed in 'compare'.
+Every selected column is shown once, followed by a diff column per comparison, and the summary includes statistics
+for each comparison as well as all comparisons combined. With 'changed-only', a record is kept if any comparison changed.
+
+Example:
+diffhtml --csv=translations.csv --baseline 1 --compare 2,3,4 -o report.html
+
+JSON OUTPUT
+If 'output-format' is set to 'json', then a JSON document is generated instead of HTML.
+Each record includes its inputs, row number (or line numbers with the 'files' option), the diff as an array
+of {"tag": "same|added|removed", "text": "..."} segments, and token statistics. Summary statistics for all
+records are included at the end of the document. A single pair diff is printed as a single JSON record.
+With multiple comparisons, each record also has "values" and a "comparisons" array with the label, inputs, diff,
+and statistics of each comparison.
+
+CSV OUTPUT
+If 'output-format' is set to 'csv', then the input file is written back out with these columns appended to each row.
+	diff            The diff in word-diff notation, like 'a [-removed-]{+added+} string'.
+	tokens_added    The number of tokens added in B.
+	tokens_removed  The number of tokens removed from A.
+	similarity      The proportion of tokens that are the same, from 0 to 1.
+	changed         TRUE if any tokens were added or removed, otherwise FALSE.
+The header row (if any) is extended with the column names above, and TSV input produces TSV output.
+Each record is written exactly as it was read, including its quoting and line ending, with the annotation columns appended.
+With multiple comparisons, these columns are appended for each comparison, with the comparison label in parentheses.
+
+CUSTOM TEMPLATES
+The 'template' option replaces the embedded HTML report template with a Go html/template file.
+The file must define the "header", "row", and "footer" templates, which are executed once at the start of each page,
+once per record, and once at the end of each page respectively. The template is validated at startup, and referencing
+a field that the data in scope doesn't have, like .Record in "header" or .Stats directly in "row", is an error. Values are escaped for the context they appear in, and .DiffHTML and
+segmentHTML escape the compared text before adding their spans.
+
+The "header" and "footer" templates receive a page:
+	.FileName     The input file name.
+	.HeaderA      The label for sample A.
+	.HeaderB      The label for sample B.
+	.Columns      The label of each selected column.
+	.Comparisons  The label of each comparison.
+	.LineNumbers  True if records have line numbers instead of row numbers.
+	.Schema       Column changes in a table comparison with .OldColumns .NewColumns .Added .Removed .Reordered .Changed
+	              or nil for other reports.
+	.IndexLink    A link back to the index page in a directory comparison, or empty.
+	.Page         The 1-indexed page number.
+	.PrevPage     A link to the previous page, or empty.
+	.NextPage     A link to the next page, or empty.
+	.Records      The number of records on this page (only in the footer).
+	.Summary      Statistics for the whole report (only in the footer of the last page, otherwise nil).
+	              Fields: .Records .Changed .Unchanged .TokensAdded .TokensRemoved .MeanSimilarity .MinSimilarity .Distribution
+	              Each distribution bucket has .Label .Count .Percent
+	              With multiple comparisons, .Comparisons holds a summary per comparison, each with a .Label
+	              In a table comparison, .Statuses maps each row status to the number of rows
+
+The "row" template receives a row:
+	.LineNumbers  True if records have line numbers instead of row numbers.
+	.Record.A     Sample A.
+	.Record.B     Sample B.
+	.Record.Row   The 1-indexed record number, if not using line numbers.
+	.Record.Status  The row status in a table comparison: added, removed, changed, or identical.
+	.Record.LineA The line number of A, or 0 if it was added.
+	.Record.LineB The line number of B, or 0 if it was removed.
+	.Record.Stats Token statistics with .Same .Added .Removed .Similarity .Changed
+	.Record.DiffHTML  The diff rendered with the default HTML spans.
+	.Record.Values      The value of each selected column, in the same order as the page .Columns.
+	.Record.Comparisons Each comparison, with .A .B .Label .Stats .DiffHTML
+	                    .Record.A, .Record.B, and .Record.DiffHTML refer to the first comparison.
+	                    .Record.Stats combines the statistics of all comparisons.
+
+Helper functions:
+	segments RECORD   Returns the diff segments of a record, each with .Tag and .Text.
+	segmentHTML SEG   Renders a segment with the default HTML spans.
+	wordDiff RECORD   Renders the diff of a record in word-diff notation.
+	changes STATS     Returns the number of tokens added and removed.
+	percent FLOAT     Formats a 0 to 1 value as a percentage.
+	plusOne INT       Adds one to an integer.
+
+Example row template:
+	{{define "row"}}<tr><td>{{range segments .Record}}<span class="{{.Tag}}">{{.Text}}</span>{{end}}</td></tr>{{end}}
+
+CONFIG FILES
+The 'config' option loads settings from a YAML file, where each key is the name of a flag without the leading dashes.
+Settings are layered, so the config file overrides defaults, environment variables override the config file, and flags
+override everything. Each flag has an environment variable named like DIFFHTML_HEADER_A for 'header-a', and the config
+file itself may be given with DIFFHTML_CONFIG. List values may be used for repeatable flags like 'pair', and 'args'
+lists the positional arguments, like the files to compare.
+
+A config file may define named report jobs, which override the shared settings at the top level of the file.
+Every job is run by default, or the 'job' option selects some of them by name. Use 'print-config' to check the
+effective settings of each job, which are printed in the same format as the config file.
+
+Example:
+	delim: " ;"
+	matchahead: 4
+	jobs:
+	  - name: orders
+	    csv: orders.csv
+	    col-a: expected
+	    col-b: actual
+	    out: orders.html
+	  - name: customers
+	    tables: true
+	    key: [id]
+	    args: [customers-old.csv, customers-new.csv]
+	    out: customers.html
+
+With several jobs, the exit code is the highest of any job.
+
+EXIT CODES
+By default, diffhtml exits with 0 on success and 1 for errors. The 'exit-code', 'min-similarity', and 'max-changed' options
+make the exit code useful for gating CI jobs, in which case errors exit with 2 instead.
+	exit-code       Exits with 1 if any record changed.
+	min-similarity  Exits with 1 if any record's similarity is below the threshold.
+	max-changed     Exits with 1 if more than this many records changed.
+Thresholds apply on their own, so a job can allow small changes without 'exit-code'. Records are always counted
+as a whole, with multiple comparisons combined, and 'changed-only' doesn't affect the result.
+The report is always written before exiting, so failures can be inspected.
+
+Example:
+diffhtml --csv=golden.csv -a expected -b actual --min-similarity=0.9 --max-changed=10 -o report.html || exit $?
+
+INPUT FORMATS
+The input file format is selected with the 'format' option, and an input file name of '-' reads from STDIN.
+	csv    Comma separated values. Columns are selected with 'col-a' and 'col-b'.
+	tsv    Tab separated values. Columns are selected with 'col-a' and 'col-b'.
+	jsonl  JSON Lines, one object per line. Fields are selected with 'field-a' and 'field-b'.
+
+CSV and TSV columns may be selected by 0-indexed position, or by header name when 'skip-header' is set.
+A name is matched exactly first, then case-insensitively, and a name that doesn't match lists the available headers.
+Column names also work with 'pair', 'baseline', and 'compare'. A column that is all digits is always treated as a position.
+Unless 'header-a' or 'header-b' are given, the header text of the selected columns is used as the report labels.
+
+JSON Lines field paths are dot separated keys with optional array indexes, like 'user.names[0]'.
+String values are compared as-is, null is treated as an empty string, and other values are compared as JSON text.
+
+Example:
+jq -c '.items[]' export.json | diffhtml --in=- --format=jsonl --field-a=old.name --field-b=new.name
+
+FLAGS
+%s`, flags.FlagUsages())
+		// The usage is written for diffhtml, and the name is replaced when running as a linediff subcommand.
+		fmt.Print(strings.ReplaceAll(usage, "diffhtml", name))
+	}
+
+	return flags
+}
@@ -0,0 +1,506 @@
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatJSONL = "jsonl"
+)
+
+var (
+	ErrInvalidFormat    = errors.New("invalid input format")
+	ErrInvalidFieldPath = errors.New("invalid field path")
+	ErrUnknownColumn    = errors.New("unknown column")
+)
+
+// recordReader produces the values of selected columns from an input source.
+type recordReader interface {
+	// Read returns the selected values of the next record, or io.EOF when the input is exhausted.
+	Read() ([]string, error)
+	// Labels returns a default label for each selected column.
+	Labels() []string
+}
+
+// comparisonSpec compares two selected columns by their position in the selection.
+type comparisonSpec struct {
+	A, B  int
+	Label string
+}
+
+// columnSelection is the set of columns read from each record, and the comparisons between them.
+type columnSelection struct {
+	columns []string
+	specs   []comparisonSpec
+}
+
+// selectColumns determines the selected columns and comparisons from the configuration.
+// The 'pair' option takes precedence over 'baseline' and 'compare', which take precedence over the A and B column options.
+func selectColumns(config *Config) (columnSelection, error) {
+	var sel columnSelection
+	indexOf := func(column string) int {
+		for i, c := range sel.columns {
+			if c == column {
+				return i
+			}
+		}
+		sel.columns = append(sel.columns, column)
+		return len(sel.columns) - 1
+	}
+
+	switch {
+	case len(config.Pairs) > 0:
+		for _, pair := range config.Pairs {
+			a, b, found := strings.Cut(pair, ":")
+			if !found || len(a) == 0 || len(b) == 0 {
+				return sel, fmt.Errorf("%w: pair '%s' must be in the form 'A:B'", ErrInvalidColIndex, pair)
+			}
+			if a == b {
+				return sel, fmt.Errorf("%w: pair '%s' compares a column to itself", ErrInvalidColIndex, pair)
+			}
+			sel.specs = append(sel.specs, comparisonSpec{A: indexOf(a), B: indexOf(b)})
+		}
+	case len(config.Baseline) > 0:
+		if len(config.Compare) == 0 {
+			return sel, fmt.Errorf("%w: at least one 'compare' column is required with 'baseline'", ErrInvalidColIndex)
+		}
+		base := indexOf(config.Baseline)
+		for _, c := range config.Compare {
+			if c == config.Baseline {
+				return sel, fmt.Errorf("%w: baseline '%s' cannot be compared to itself", ErrInvalidColIndex, c)
+			}
+			sel.specs = append(sel.specs, comparisonSpec{A: base, B: indexOf(c)})
+		}
+	case config.Format == FormatJSONL:
+		sel.columns = []string{config.AField, config.BField}
+		sel.specs = []comparisonSpec{{A: 0, B: 1}}
+	default:
+		if len(config.ACol) == 0 {
+			return sel, fmt.Errorf("%w: column A is required", ErrInvalidColIndex)
+		}
+		if len(config.BCol) == 0 {
+			return sel, fmt.Errorf("%w: column B is required", ErrInvalidColIndex)
+		}
+		if config.ACol == config.BCol {
+			return sel, fmt.Errorf("%w: column indexes cannot be the same", ErrInvalidColIndex)
+		}
+		sel.columns = []string{config.ACol, config.BCol}
+		sel.specs = []comparisonSpec{{A: 0, B: 1}}
+	}
+	return sel, nil
+}
+
+// openInput opens the named input file, or STDIN if the name is "-".
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open input file '%s'", err, name)
+	}
+	return in, nil
+}
+
+// newRecordReader creates a recordReader for the configured format, which reads the selected columns.
+// The header row of a CSV or TSV file is read immediately if 'skip-header' is set, so columns can be selected by name.
+func newRecordReader(config *Config, in io.Reader, sel columnSelection) (recordReader, error) {
+	switch config.Format {
+	case FormatCSV, FormatTSV:
+		r := &delimitedReader{
+			fields: newFieldReader(config, in),
+			comma:  delimiter(config),
+			cols:   make([]int, len(sel.columns)),
+		}
+		if config.SkipFirstRow {
+			if err := r.readHeader(); err != nil {
+				return nil, err
+			}
+		}
+		for i, column := range sel.columns {
+			col, err := resolveColumn(r.header, column)
+			if err != nil {
+				return nil, err
+			}
+			r.cols[i] = col
+		}
+		for _, spec := range sel.specs {
+			if r.cols[spec.A] == r.cols[spec.B] {
+				return nil, fmt.Errorf("%w: columns '%s' and '%s' are the same column", ErrInvalidColIndex, sel.columns[spec.A], sel.columns[spec.B])
+			}
+		}
+		return r, nil
+	case FormatJSONL:
+		paths := make([]fieldPath, len(sel.columns))
+		for i, column := range sel.columns {
+			path, err := parseFieldPath(column)
+			if err != nil {
+				return nil, err
+			}
+			paths[i] = path
+		}
+		dec := json.NewDecoder(in)
+		dec.UseNumber()
+		return &jsonlReader{
+			dec:   dec,
+			paths: paths,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: '%s' is not one of %s, %s, or %s", ErrInvalidFormat, config.Format, FormatCSV, FormatTSV, FormatJSONL)
+	}
+}
+
+// fieldReader reads the fields of each CSV or TSV record.
+type fieldReader interface {
+	// Read returns the fields of the next record, and the text they were read from, including the line ending and any blank lines before it.
+	Read() (record []string, raw string, err error)
+}
+
+// newFieldReader creates a fieldReader for the configured CSV or TSV format.
+func newFieldReader(config *Config, in io.Reader) fieldReader {
+	if config.Format == FormatTSV {
+		return &tsvReader{br: bufio.NewReader(in)}
+	}
+	rec := &recordingReader{r: in}
+	return &csvReader{csvr: csv.NewReader(rec), rec: rec}
+}
+
+// delimiter returns the field delimiter of the configured CSV or TSV format.
+func delimiter(config *Config) rune {
+	if config.Format == FormatTSV {
+		return '\t'
+	}
+	return ','
+}
+
+// readAllFields reads every remaining record from the fieldReader.
+func readAllFields(r fieldReader) ([][]string, error) {
+	var records [][]string
+	for {
+		record, _, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// tsvReader reads tab separated records, which have no quoting, so quotes are read like any other rune.
+// A carriage return before a line feed is removed, and blank lines are skipped like encoding/csv does.
+type tsvReader struct {
+	br   *bufio.Reader
+	line int
+	// fields is the number of fields in the first record, which every record must have.
+	fields int
+}
+
+func (r *tsvReader) Read() ([]string, string, error) {
+	var raw strings.Builder
+	for {
+		line, err := r.br.ReadString('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, "", err
+		}
+		r.line++
+		raw.WriteString(line)
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		record := strings.Split(line, "\t")
+		if r.fields == 0 {
+			r.fields = len(record)
+		} else if len(record) != r.fields {
+			return nil, "", fmt.Errorf("record on line %d: %w", r.line, csv.ErrFieldCount)
+		}
+		return record, raw.String(), nil
+	}
+}
+
+// csvReader reads CSV records with encoding/csv, along with the text of each record.
+type csvReader struct {
+	csvr *csv.Reader
+	rec  *recordingReader
+}
+
+func (r *csvReader) Read() ([]string, string, error) {
+	record, err := r.csvr.Read()
+	if err != nil {
+		return nil, "", err
+	}
+	return record, r.rec.take(r.csvr.InputOffset()), nil
+}
+
+// recordingReader keeps the bytes read from its input until they're taken, since encoding/csv doesn't return the text of a record.
+type recordingReader struct {
+	r   io.Reader
+	buf []byte
+	// offset is the input offset of the start of buf.
+	offset int64
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	return n, err
+}
+
+// take returns the text from the last take up to the input offset, and discards it.
+func (rr *recordingReader) take(offset int64) string {
+	n := int(offset - rr.offset)
+	text := string(rr.buf[:n])
+	rr.buf = append(rr.buf[:0], rr.buf[n:]...)
+	rr.offset = offset
+	return text
+}
+
+// resolveColumn returns the index of a column given by position or header name.
+// Names are matched exactly first, then case-insensitively, as long as only one header matches.
+func resolveColumn(header []string, column string) (int, error) {
+	if col, err := strconv.Atoi(column); err == nil {
+		if col < 0 {
+			return 0, fmt.Errorf("%w: column index '%s' is invalid", ErrInvalidColIndex, column)
+		}
+		return col, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("%w: '%s' can't be matched by name without a header row", ErrUnknownColumn, column)
+	}
+	for i, name := range header {
+		if name == column {
+			return i, nil
+		}
+	}
+	var matches []int
+	for i, name := range header {
+		if strings.EqualFold(name, column) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return 0, fmt.Errorf("%w: '%s' is not one of the available headers %s", ErrUnknownColumn, column, quoteList(header))
+	default:
+		names := make([]string, len(matches))
+		for i, col := range matches {
+			names[i] = header[col]
+		}
+		return 0, fmt.Errorf("%w: '%s' matches more than one header %s", ErrUnknownColumn, column, quoteList(names))
+	}
+}
+
+// quoteList formats names as a comma separated list of quoted strings.
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// delimitedReader reads selected columns of CSV or TSV records.
+// The text of the header and most recently read record are retained so they can be written back out.
+type delimitedReader struct {
+	fields    fieldReader
+	comma     rune
+	cols      []int
+	row       int
+	header    []string
+	rawHeader string
+	raw       string
+}
+
+func (r *delimitedReader) readHeader() error {
+	header, raw, err := r.fields.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	r.row++
+	r.header = trimBOM(header)
+	r.rawHeader = raw
+	return nil
+}
+
+func (r *delimitedReader) Read() ([]string, error) {
+	record, raw, err := r.fields.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read CSV record: %w", err)
+	}
+	if r.row == 0 {
+		record = trimBOM(record)
+	}
+	r.row++
+	values := make([]string, len(r.cols))
+	for i, col := range r.cols {
+		if col >= len(record) {
+			return nil, fmt.Errorf("one or more column index is out of bounds for row %d", r.row)
+		}
+		values[i] = record[col]
+	}
+	r.raw = raw
+	return values, nil
+}
+
+// Labels returns the header text of each selected column if there is a header, otherwise labels like 'Column 0'.
+func (r *delimitedReader) Labels() []string {
+	labels := make([]string, len(r.cols))
+	for i, col := range r.cols {
+		if col < len(r.header) {
+			labels[i] = r.header[col]
+		} else {
+			labels[i] = fmt.Sprintf("Column %d", col)
+		}
+	}
+	return labels
+}
+
+// trimBOM removes a UTF-8 byte order mark from the first field of a file's first record, which spreadsheet programs often write.
+func trimBOM(record []string) []string {
+	if len(record) > 0 {
+		record[0] = strings.TrimPrefix(record[0], "\ufeff")
+	}
+	return record
+}
+
+// jsonlReader reads selected fields of JSON Lines objects.
+type jsonlReader struct {
+	dec   *json.Decoder
+	paths []fieldPath
+	line  int
+}
+
+func (r *jsonlReader) Read() ([]string, error) {
+	var value any
+	if err := r.dec.Decode(&value); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read JSON record %d: %w", r.line+1, err)
+	}
+	r.line++
+	values := make([]string, len(r.paths))
+	for i, path := range r.paths {
+		v, err := path.lookup(value)
+		if err != nil {
+			return nil, fmt.Errorf("JSON record %d: %w", r.line, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Labels returns the field path of each selected field.
+func (r *jsonlReader) Labels() []string {
+	labels := make([]string, len(r.paths))
+	for i, path := range r.paths {
+		labels[i] = path.source
+	}
+	return labels
+}
+
+// pathElem is either an object key or an array index within a fieldPath.
+type pathElem struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// fieldPath selects a value within a JSON document.
+type fieldPath struct {
+	source string
+	elems  []pathElem
+}
+
+// parseFieldPath parses a field path in the form 'a.b[0].c'.
+// A leading '.' is accepted for familiarity with jq.
+func parseFieldPath(path string) (fieldPath, error) {
+	fp := fieldPath{source: path}
+	p := strings.TrimPrefix(path, ".")
+	if len(p) == 0 {
+		return fp, fmt.Errorf("%w: path is empty", ErrInvalidFieldPath)
+	}
+	for _, part := range strings.Split(p, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if len(key) > 0 {
+			fp.elems = append(fp.elems, pathElem{key: key, isKey: true})
+		} else if len(rest) == 0 {
+			return fp, fmt.Errorf("%w: empty element in path '%s'", ErrInvalidFieldPath, path)
+		}
+		for len(rest) > 0 {
+			idx, remaining, found := strings.Cut(rest, "]")
+			if !found {
+				return fp, fmt.Errorf("%w: unclosed index in path '%s'", ErrInvalidFieldPath, path)
+			}
+			i, err := strconv.Atoi(idx)
+			if err != nil || i < 0 {
+				return fp, fmt.Errorf("%w: invalid index '%s' in path '%s'", ErrInvalidFieldPath, idx, path)
+			}
+			fp.elems = append(fp.elems, pathElem{index: i})
+			if len(remaining) > 0 && remaining[0] != '[' {
+				return fp, fmt.Errorf("%w: unexpected '%s' in path '%s'", ErrInvalidFieldPath, remaining, path)
+			}
+			rest = strings.TrimPrefix(remaining, "[")
+		}
+	}
+	return fp, nil
+}
+
+// lookup finds the value at the path and returns it as sample text.
+// String values are returned as-is, null is returned as an empty string, and anything else is returned as its JSON encoding.
+func (fp fieldPath) lookup(value any) (string, error) {
+	cur := value
+	for _, elem := range fp.elems {
+		if elem.isKey {
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("'%s' is not an object at key '%s'", fp.source, elem.key)
+			}
+			cur, ok = obj[elem.key]
+			if !ok {
+				return "", fmt.Errorf("'%s' not found: missing key '%s'", fp.source, elem.key)
+			}
+			continue
+		}
+		arr, ok := cur.([]any)
+		if !ok {
+			return "", fmt.Errorf("'%s' is not an array at index %d", fp.source, elem.index)
+		}
+		if elem.index >= len(arr) {
+			return "", fmt.Errorf("'%s' not found: index %d is out of bounds", fp.source, elem.index)
+		}
+		cur = arr[elem.index]
+	}
+	switch v := cur.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,278 @@
+package report
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := map[string]struct {
+		path  string
+		elems []pathElem
+		err   bool
+	}{
+		"Key": {
+			path:  "a",
+			elems: []pathElem{{key: "a", isKey: true}},
+		},
+		"Leading dot": {
+			path:  ".a.b",
+			elems: []pathElem{{key: "a", isKey: true}, {key: "b", isKey: true}},
+		},
+		"Indexes": {
+			path:  "a.b[0][2].c",
+			elems: []pathElem{{key: "a", isKey: true}, {key: "b", isKey: true}, {index: 0}, {index: 2}, {key: "c", isKey: true}},
+		},
+		"Root index": {
+			path:  "[1].a",
+			elems: []pathElem{{index: 1}, {key: "a", isKey: true}},
+		},
+		"Empty": {
+			path: ".",
+			err:  true,
+		},
+		"Empty element": {
+			path: "a..b",
+			err:  true,
+		},
+		"Unclosed index": {
+			path: "a[0",
+			err:  true,
+		},
+		"Negative index": {
+			path: "a[-1]",
+			err:  true,
+		},
+		"Non-numeric index": {
+			path: "a[x]",
+			err:  true,
+		},
+		"Text after index": {
+			path: "a[0]b",
+			err:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fp, err := parseFieldPath(tc.path)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrInvalidFieldPath)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.path, fp.source)
+			assert.Equal(t, tc.elems, fp.elems)
+		})
+	}
+}
+
+func TestNewRecordReader(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		input   string
+		columns []string
+		labels  []string
+		records [][]string
+		err     string
+	}{
+		"CSV": {
+			format:  FormatCSV,
+			input:   "id,a,b\n1,\"x, y\",z\n",
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			records: [][]string{{"x, y", "z"}},
+		},
+		"TSV": {
+			format:  FormatTSV,
+			input:   "id\ta\tb\n1\tsay \"hi\"\tz\n",
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			records: [][]string{{"say \"hi\"", "z"}},
+		},
+		"TSV with unmatched quote": {
+			format:  FormatTSV,
+			input:   "id\ta\tb\r\n1\t\"x\ty\r\n\r\n2\tz\t\"w\r\n",
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			records: [][]string{{"\"x", "y"}, {"z", "\"w"}},
+		},
+		"TSV wrong number of fields": {
+			format:  FormatTSV,
+			input:   "id\ta\tb\n1\tx\n",
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			err:     "failed to read CSV record: record on line 2: wrong number of fields",
+		},
+		"JSON Lines": {
+			format:  FormatJSONL,
+			input:   `{"a": {"b": ["x", "y"]}, "n": 1.50}` + "\n" + `{"a": {"b": [null, "z"]}, "n": {"c": true}}` + "\n",
+			columns: []string{"a.b[1]", ".n"},
+			labels:  []string{"a.b[1]", ".n"},
+			records: [][]string{{"y", "1.50"}, {"z", `{"c":true}`}},
+		},
+		"JSON Lines null": {
+			format:  FormatJSONL,
+			input:   `{"a": null, "b": "x"}`,
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			records: [][]string{{"", "x"}},
+		},
+		"JSON Lines missing key": {
+			format:  FormatJSONL,
+			input:   `{"a": "x"}`,
+			columns: []string{"a", "b"},
+			labels:  []string{"a", "b"},
+			err:     "JSON record 1: 'b' not found: missing key 'b'",
+		},
+		"JSON Lines index out of bounds": {
+			format:  FormatJSONL,
+			input:   `{"a": ["x"]}`,
+			columns: []string{"a[0]", "a[1]"},
+			labels:  []string{"a[0]", "a[1]"},
+			err:     "JSON record 1: 'a[1]' not found: index 1 is out of bounds",
+		},
+		"CSV column out of bounds": {
+			format:  FormatCSV,
+			input:   "a,b,c\n1,2,3\n",
+			columns: []string{"a", "3"},
+			labels:  []string{"a", "Column 3"},
+			err:     "one or more column index is out of bounds for row 2",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &Config{Format: tc.format, SkipFirstRow: true}
+			sel := columnSelection{columns: tc.columns, specs: []comparisonSpec{{A: 0, B: 1}}}
+			r, err := newRecordReader(config, strings.NewReader(tc.input), sel)
+			require.NoError(t, err)
+			assert.Equal(t, tc.labels, r.Labels())
+
+			var records [][]string
+			for {
+				values, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if len(tc.err) > 0 {
+					assert.EqualError(t, err, tc.err)
+					return
+				}
+				require.NoError(t, err)
+				records = append(records, values)
+			}
+			assert.Empty(t, tc.err, "Expected an error")
+			assert.Equal(t, tc.records, records)
+		})
+	}
+}
+
+func TestResolveColumn(t *testing.T) {
+	header := []string{"id", "Name", "name", "Email"}
+	tests := map[string]struct {
+		header []string
+		column string
+		col    int
+		err    error
+	}{
+		"Index": {
+			header: header,
+			column: "2",
+			col:    2,
+		},
+		"Index without header": {
+			column: "5",
+			col:    5,
+		},
+		"Negative index": {
+			header: header,
+			column: "-1",
+			err:    ErrInvalidColIndex,
+		},
+		"Exact name": {
+			header: header,
+			column: "name",
+			col:    2,
+		},
+		"Case-insensitive name": {
+			header: header,
+			column: "EMAIL",
+			col:    3,
+		},
+		"Ambiguous name": {
+			header: header,
+			column: "NAME",
+			err:    ErrUnknownColumn,
+		},
+		"Unknown name": {
+			header: header,
+			column: "phone",
+			err:    ErrUnknownColumn,
+		},
+		"Name without header": {
+			column: "id",
+			err:    ErrUnknownColumn,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			col, err := resolveColumn(tc.header, tc.column)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.col, col)
+		})
+	}
+}
+
+func TestNewRecordReader_BOM(t *testing.T) {
+	tests := map[string]struct {
+		skipHeader bool
+		columns    []string
+		labels     []string
+		values     []string
+	}{
+		"Header": {
+			skipHeader: true,
+			columns:    []string{"id", "name"},
+			labels:     []string{"id", "name"},
+			values:     []string{"1", "a"},
+		},
+		"No header": {
+			columns: []string{"0", "1"},
+			labels:  []string{"Column 0", "Column 1"},
+			values:  []string{"id", "name"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &Config{Format: FormatCSV, SkipFirstRow: tc.skipHeader}
+			sel := columnSelection{columns: tc.columns, specs: []comparisonSpec{{A: 0, B: 1}}}
+			r, err := newRecordReader(config, strings.NewReader("\ufeffid,name\n1,a\n"), sel)
+			require.NoError(t, err)
+			assert.Equal(t, tc.labels, r.Labels())
+			values, err := r.Read()
+			require.NoError(t, err)
+			assert.Equal(t, tc.values, values)
+		})
+	}
+}
+
+func TestReadTable_BOM(t *testing.T) {
+	path := writeTestFile(t, "table.csv", "\ufeffid,name\n1,a\n")
+	table, err := readTable(&Config{Format: FormatCSV, SkipFirstRow: true}, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name"}, table.header)
+
+	col, err := resolveColumn(table.header, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 0, col)
+}
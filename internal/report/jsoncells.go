@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/drognisep/linediff"
+	"io"
+	"sort"
+	"strings"
+)
+
+// normalizeJSONCell rewrites a cell holding a JSON object or array with sorted keys and a single space after each comma and colon,
+// so key order and formatting don't affect joins, alignment, or diffs. Numbers are kept as written.
+// Any other cell is returned as-is, including cells with a JSON string or number, which are compared as text.
+func normalizeJSONCell(cell string) string {
+	trimmed := strings.TrimSpace(cell)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return cell
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return cell
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return cell
+	}
+	var buf strings.Builder
+	writeJSONValue(&buf, value)
+	return buf.String()
+}
+
+func writeJSONValue(buf *strings.Builder, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeJSONString(buf, key)
+			buf.WriteString(": ")
+			writeJSONValue(buf, v[key])
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeJSONValue(buf, elem)
+		}
+		buf.WriteByte(']')
+	case string:
+		writeJSONString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// writeJSONString writes a quoted JSON string without escaping HTML characters, since reports escape their own output.
+func writeJSONString(buf *strings.Builder, s string) {
+	var quoted bytes.Buffer
+	enc := json.NewEncoder(&quoted)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Write(bytes.TrimSuffix(quoted.Bytes(), []byte("\n")))
+}
+
+// equalJSONCells returns true if both normalized cells hold JSON documents that are structurally equal, like numbers
+// written differently with the same value.
+func equalJSONCells(a, b string) bool {
+	if !strings.HasPrefix(a, "{") && !strings.HasPrefix(a, "[") {
+		return false
+	}
+	diff, err := linediff.DiffJSON([]byte(a), []byte(b))
+	return err == nil && !diff.Changed()
+}
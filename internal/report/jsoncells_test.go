@@ -0,0 +1,95 @@
+package report
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeJSONCell(t *testing.T) {
+	tests := map[string]struct {
+		cell     string
+		expected string
+	}{
+		"Object": {
+			cell:     ` {"b":[1,2.50,{"d":null,"c":true}],"a":"x<y"} `,
+			expected: `{"a": "x<y", "b": [1, 2.50, {"c": true, "d": null}]}`,
+		},
+		"Array": {
+			cell:     "[\n  \"a\",\n  false\n]",
+			expected: `["a", false]`,
+		},
+		"Plain text": {
+			cell:     "hello world",
+			expected: "hello world",
+		},
+		"Number": {
+			cell:     "1.0",
+			expected: "1.0",
+		},
+		"Invalid JSON": {
+			cell:     `{"a": }`,
+			expected: `{"a": }`,
+		},
+		"Trailing data": {
+			cell:     `{"a": 1} extra`,
+			expected: `{"a": 1} extra`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeJSONCell(tc.cell))
+		})
+	}
+}
+
+func TestEqualJSONCells(t *testing.T) {
+	assert.True(t, equalJSONCells(`{"a": 1.0}`, `{"a": 1}`))
+	assert.False(t, equalJSONCells(`{"a": 1}`, `{"a": 2}`))
+	assert.False(t, equalJSONCells(`{"id": 9007199254740993}`, `{"id": 9007199254740992}`), "Large integers should be compared exactly")
+	assert.False(t, equalJSONCells("1.0", "1"), "Only objects and arrays are compared as JSON")
+}
+
+func TestTables_JSONCells(t *testing.T) {
+	oldFile := writeTestFile(t, "old.csv", "id,data\n1,\"{\"\"a\"\": 1, \"\"b\"\": [1, 2]}\"\n2,\"{\"\"n\"\": 1.0}\"\n3,\"{\"\"a\"\": \"\"old\"\"}\"\n")
+	newFile := writeTestFile(t, "new.csv", "id,data\n1,\"{\"\"b\"\":[1,2],\"\"a\"\":1}\"\n2,\"{\"\"n\"\": 1}\"\n3,\"{\"\"a\"\": \"\"new\"\"}\"\n")
+	tests := map[string]struct {
+		args     []string
+		statuses []string
+	}{
+		"Text cells": {
+			statuses: []string{StatusChanged, StatusChanged, StatusChanged},
+		},
+		"JSON cells": {
+			args:     []string{"--json-cells"},
+			statuses: []string{StatusIdentical, StatusIdentical, StatusChanged},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.json")
+			args := append([]string{"--tables", "--key", "id", "--output-format", "json", "-o", out}, tc.args...)
+			_, err := runTestReport(t, append(args, oldFile, newFile)...)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(out)
+			require.NoError(t, err)
+			var report struct {
+				Records []struct {
+					Status string `json:"status"`
+				} `json:"records"`
+			}
+			require.NoError(t, json.Unmarshal(data, &report))
+			var statuses []string
+			for _, r := range report.Records {
+				statuses = append(statuses, r.Status)
+			}
+			assert.Equal(t, tc.statuses, statuses)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff"
+)
+
+const (
+	OutputHTML = "html"
+	OutputJSON = "json"
+	OutputCSV  = "csv"
+)
+
+var ErrInvalidOutputFormat = errors.New("invalid output format")
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case OutputHTML, OutputJSON, OutputCSV:
+		return nil
+	default:
+		return fmt.Errorf("%w: '%s' is not one of %s, %s, or %s", ErrInvalidOutputFormat, format, OutputHTML, OutputJSON, OutputCSV)
+	}
+}
+
+// jsonRecord is a single record in JSON output.
+// The top level A, B, diff, and stats are for the first comparison, and every comparison is listed when there's more than one.
+// Keyed table rows always list every comparison, with the key column values in Values.
+type jsonRecord struct {
+	Row         int                `json:"row,omitempty"`
+	LineA       int                `json:"lineA,omitempty"`
+	LineB       int                `json:"lineB,omitempty"`
+	Status      string             `json:"status,omitempty"`
+	A           string             `json:"a"`
+	B           string             `json:"b"`
+	Diff        *linediff.DiffSet  `json:"diff"`
+	Stats       linediff.DiffStats `json:"stats"`
+	Values      []string           `json:"values,omitempty"`
+	Comparisons []jsonComparison   `json:"comparisons,omitempty"`
+}
+
+type jsonComparison struct {
+	Label string             `json:"label"`
+	A     string             `json:"a"`
+	B     string             `json:"b"`
+	Diff  *linediff.DiffSet  `json:"diff"`
+	Stats linediff.DiffStats `json:"stats"`
+}
+
+func newJSONRecord(r *diffRecord) jsonRecord {
+	ds := r.Diff()
+	jr := jsonRecord{
+		Row:    r.Row,
+		LineA:  r.LineA,
+		LineB:  r.LineB,
+		Status: r.Status,
+		A:      r.A,
+		B:      r.B,
+		Diff:   ds,
+		Stats:  ds.Stats(),
+	}
+	if len(r.Comparisons) > 1 || len(r.Status) > 0 {
+		jr.Values = r.Values
+		for _, c := range r.Comparisons {
+			cds := c.Diff()
+			jr.Comparisons = append(jr.Comparisons, jsonComparison{
+				Label: c.Label,
+				A:     c.A,
+				B:     c.B,
+				Diff:  cds,
+				Stats: cds.Stats(),
+			})
+		}
+	}
+	return jr
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"io"
+	"log"
+	"strings"
+)
+
+// readLines reads all lines from the named file, without line terminators.
+func readLines(name string) ([]string, error) {
+	in, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	var (
+		lines []string
+		br    = bufio.NewReader(in)
+	)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+			lines = append(lines, line)
+		}
+		if err != nil {
+			if err == io.EOF {
+				return lines, nil
+			}
+			return nil, fmt.Errorf("failed to read file '%s': %w", name, err)
+		}
+	}
+}
+
+// alignFileLines aligns the lines of two files, pairing changed lines so they may be token diffed.
+// Line numbers in the resulting records are 1-indexed, and 0 indicates that the line doesn't exist in that file.
+func alignFileLines(oldLines, newLines []string, differ *linediff.Differ) []*diffRecord {
+	pairs := linediff.PairChanges(linediff.AlignStrings(oldLines, newLines))
+	records := make([]*diffRecord, 0, len(pairs))
+	for _, pair := range pairs {
+		var a, b string
+		if pair.A >= 0 {
+			a = oldLines[pair.A]
+		}
+		if pair.B >= 0 {
+			b = newLines[pair.B]
+		}
+		record := newPairRecord(a, b, differ)
+		record.LineA = pair.A + 1
+		record.LineB = pair.B + 1
+		records = append(records, record)
+	}
+	return records
+}
+
+// runLineFileGeneration diffs two text files line by line, and generates an HTML report of the aligned lines.
+func runLineFileGeneration(config *Config, oldFile, newFile string) (*reportSummary, error) {
+	if oldFile == "-" && newFile == "-" {
+		return nil, fmt.Errorf("only one file may be read from STDIN")
+	}
+	log.Println("Reading input files...")
+	oldLines, err := readLines(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newLines, err := readLines(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Aligning lines...")
+	records := alignFileLines(oldLines, newLines, newDiffer(config))
+
+	w, err := newReportWriter(config, reportPage{
+		FileName:    fmt.Sprintf("%s → %s", oldFile, newFile),
+		HeaderA:     config.ALabel,
+		HeaderB:     config.BLabel,
+		Columns:     []string{config.ALabel, config.BLabel},
+		Comparisons: []string{"Difference"},
+		LineNumbers: true,
+	}, config.OutFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Generating report...")
+	summary, err := generateReport(config, w, sliceSource(records))
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Done")
+	return summary, nil
+}
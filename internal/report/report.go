@@ -0,0 +1,345 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// reportPage is the data passed to the "header" and "footer" templates.
+type reportPage struct {
+	FileName string
+	HeaderA  string
+	HeaderB  string
+	// Columns labels each selected input column, and Comparisons labels each diff column.
+	Columns     []string
+	Comparisons []string
+	LineNumbers bool
+	// Schema describes column changes in a keyed table comparison, and is nil otherwise.
+	Schema *tableSchema
+	// IndexLink links back to a directory comparison index, if this report is part of one.
+	IndexLink string
+	// Page is the 1-indexed page number.
+	Page int
+	// PrevPage and NextPage link to adjacent pages when the report is paginated.
+	PrevPage string
+	NextPage string
+	// Records is the number of records written to the page, which is only known in the footer.
+	Records int
+	// Summary holds statistics for the whole report, and is only set in the footer of the last page.
+	Summary *reportSummary
+}
+
+// Width returns the number of columns in the report table.
+func (p reportPage) Width() int {
+	width := len(p.Columns) + len(p.Comparisons) + 1
+	if p.LineNumbers {
+		width++
+	}
+	return width
+}
+
+// reportRow is the data passed to the "row" template.
+type reportRow struct {
+	Record      *diffRecord
+	LineNumbers bool
+}
+
+// reportWriter writes report output incrementally, so records don't need to be held in memory.
+type reportWriter interface {
+	// WriteRecord writes a single record to the report.
+	WriteRecord(r *diffRecord) error
+	// Close finishes the report with the summary of all records.
+	Close(summary *reportSummary) error
+}
+
+// recordSource returns the next record for a report, or io.EOF when there are no more.
+type recordSource func() (*diffRecord, error)
+
+// sliceSource creates a recordSource from records that are already in memory.
+func sliceSource(records []*diffRecord) recordSource {
+	var i int
+	return func() (*diffRecord, error) {
+		if i >= len(records) {
+			return nil, io.EOF
+		}
+		i++
+		return records[i-1], nil
+	}
+}
+
+// generateReport reads, diffs, and writes each record from next in turn, applying the 'changed-only' option.
+// If more than one worker is configured, then records are diffed concurrently and written in their original order.
+// The summary of all records is returned once the report is complete.
+func generateReport(config *Config, w reportWriter, next recordSource) (*reportSummary, error) {
+	summary := newReportSummary()
+	write := func(record *diffRecord) error {
+		stats := summary.AddRecord(record)
+		if config.ChangedOnly && !stats.Changed() {
+			return nil
+		}
+		return w.WriteRecord(record)
+	}
+
+	var err error
+	if config.Workers > 1 {
+		err = diffConcurrently(config.Workers, next, write)
+	} else {
+		err = diffSerially(next, write)
+	}
+	if err != nil {
+		_ = w.Close(summary)
+		return nil, err
+	}
+	return summary, w.Close(summary)
+}
+
+func diffSerially(next recordSource, write func(*diffRecord) error) error {
+	for {
+		record, err := next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := write(record); err != nil {
+			return err
+		}
+	}
+}
+
+// diffJob is a record being diffed by a worker, and done is closed once the diff is complete.
+type diffJob struct {
+	record *diffRecord
+	done   chan struct{}
+}
+
+// diffConcurrently diffs records with a pool of workers, while write is called with records in their original order.
+// At most twice the number of workers records are held in memory at once.
+func diffConcurrently(workers int, next recordSource, write func(*diffRecord) error) error {
+	var (
+		jobs     = make(chan diffJob)
+		ordered  = make(chan diffJob, 2*workers)
+		writeErr = make(chan error, 1)
+		stopped  = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.record.diffAll()
+				close(job.done)
+			}
+		}()
+	}
+	go func() {
+		var err error
+		for job := range ordered {
+			<-job.done
+			if err != nil {
+				continue
+			}
+			if err = write(job.record); err != nil {
+				close(stopped)
+			}
+		}
+		writeErr <- err
+	}()
+
+	var readErr error
+read:
+	for {
+		record, err := next()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+		job := diffJob{record: record, done: make(chan struct{})}
+		select {
+		case ordered <- job:
+		case <-stopped:
+			break read
+		}
+		jobs <- job
+	}
+	close(jobs)
+	close(ordered)
+
+	if err := <-writeErr; err != nil {
+		return err
+	}
+	return readErr
+}
+
+// pageFileName returns the file name for a page of a paginated report.
+// The first page uses the output file name as-is, and later pages add the page number before the extension.
+func pageFileName(outFile string, page int) string {
+	if page <= 1 {
+		return outFile
+	}
+	ext := filepath.Ext(outFile)
+	return strings.TrimSuffix(outFile, ext) + "-" + strconv.Itoa(page) + ext
+}
+
+// htmlReportWriter renders records with the header, row, and footer templates.
+// If pageSize is greater than 0, then a new page file is started after every pageSize records.
+type htmlReportWriter struct {
+	page     reportPage
+	out      io.WriteCloser
+	outFile  string
+	pageSize int
+	started  bool
+}
+
+func newHTMLReportWriter(page reportPage, outFile string, pageSize int) (*htmlReportWriter, error) {
+	if pageSize > 0 && outFile == "-" {
+		return nil, fmt.Errorf("paginated output must be written to a file")
+	}
+	page.Page = 1
+	return &htmlReportWriter{
+		page:     page,
+		outFile:  outFile,
+		pageSize: pageSize,
+	}, nil
+}
+
+func (w *htmlReportWriter) startPage() error {
+	out, err := createOutput(pageFileName(w.outFile, w.page.Page))
+	if err != nil {
+		return err
+	}
+	w.out = out
+	w.started = true
+	if err := templ.ExecuteTemplate(w.out, "header", w.page); err != nil {
+		return fmt.Errorf("failed to generate HTML header: %w", err)
+	}
+	return nil
+}
+
+func (w *htmlReportWriter) endPage(summary *reportSummary) error {
+	w.page.Summary = summary
+	if err := templ.ExecuteTemplate(w.out, "footer", w.page); err != nil {
+		_ = w.out.Close()
+		return fmt.Errorf("failed to generate HTML footer: %w", err)
+	}
+	return w.out.Close()
+}
+
+func (w *htmlReportWriter) WriteRecord(r *diffRecord) error {
+	if !w.started {
+		if err := w.startPage(); err != nil {
+			return err
+		}
+	}
+	if w.pageSize > 0 && w.page.Records >= w.pageSize {
+		w.page.NextPage = filepath.Base(pageFileName(w.outFile, w.page.Page+1))
+		if err := w.endPage(nil); err != nil {
+			return err
+		}
+		w.page.PrevPage = filepath.Base(pageFileName(w.outFile, w.page.Page))
+		w.page.NextPage = ""
+		w.page.Page++
+		w.page.Records = 0
+		if err := w.startPage(); err != nil {
+			return err
+		}
+	}
+	w.page.Records++
+	if err := templ.ExecuteTemplate(w.out, "row", reportRow{Record: r, LineNumbers: w.page.LineNumbers}); err != nil {
+		return fmt.Errorf("failed to generate HTML row: %w", err)
+	}
+	return nil
+}
+
+func (w *htmlReportWriter) Close(summary *reportSummary) error {
+	if !w.started {
+		if err := w.startPage(); err != nil {
+			return err
+		}
+	}
+	return w.endPage(summary)
+}
+
+// jsonReportWriter writes a JSON document incrementally, with the summary at the end.
+type jsonReportWriter struct {
+	out     io.WriteCloser
+	records int
+}
+
+func newJSONReportWriter(page reportPage, outFile string) (*jsonReportWriter, error) {
+	out, err := createOutput(outFile)
+	if err != nil {
+		return nil, err
+	}
+	w := &jsonReportWriter{out: out}
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	for _, field := range []struct{ name, value string }{
+		{"fileName", page.FileName},
+		{"headerA", page.HeaderA},
+		{"headerB", page.HeaderB},
+	} {
+		value, _ := json.Marshal(field.value)
+		buf.WriteString(fmt.Sprintf("  %q: %s,\n", field.name, value))
+	}
+	if page.Schema != nil {
+		schema, _ := json.MarshalIndent(page.Schema, "  ", "  ")
+		buf.WriteString(fmt.Sprintf("  %q: %s,\n", "schema", schema))
+	}
+	buf.WriteString(`  "records": [`)
+	if _, err := io.WriteString(out, buf.String()); err != nil {
+		_ = out.Close()
+		return nil, fmt.Errorf("failed to generate JSON file: %w", err)
+	}
+	return w, nil
+}
+
+func (w *jsonReportWriter) WriteRecord(r *diffRecord) error {
+	data, err := json.MarshalIndent(newJSONRecord(r), "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON record: %w", err)
+	}
+	sep := ",\n    "
+	if w.records == 0 {
+		sep = "\n    "
+	}
+	w.records++
+	if _, err := io.WriteString(w.out, sep+string(data)); err != nil {
+		return fmt.Errorf("failed to generate JSON file: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonReportWriter) Close(summary *reportSummary) error {
+	data, err := json.MarshalIndent(summary, "  ", "  ")
+	if err != nil {
+		_ = w.out.Close()
+		return fmt.Errorf("failed to generate JSON summary: %w", err)
+	}
+	end := "\n  ],\n"
+	if w.records == 0 {
+		end = "],\n"
+	}
+	if _, err := io.WriteString(w.out, end+`  "summary": `+string(data)+"\n}\n"); err != nil {
+		_ = w.out.Close()
+		return fmt.Errorf("failed to generate JSON file: %w", err)
+	}
+	return w.out.Close()
+}
+
+// newReportWriter creates a reportWriter for the configured output format.
+func newReportWriter(config *Config, page reportPage, outFile string) (reportWriter, error) {
+	switch config.OutputFormat {
+	case OutputJSON:
+		return newJSONReportWriter(page, outFile)
+	case OutputHTML:
+		return newHTMLReportWriter(page, outFile, config.PageSize)
+	default:
+		return nil, fmt.Errorf("%w: '%s' isn't supported for this report", ErrInvalidOutputFormat, config.OutputFormat)
+	}
+}
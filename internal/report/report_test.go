@@ -0,0 +1,188 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRecords creates records comparing "a i" to "b i", numbered from 1.
+func testRecords(n int) []*diffRecord {
+	var (
+		records = make([]*diffRecord, n)
+		differ  = linediff.NewDiffer(linediff.SplitSpaces)
+	)
+	for i := range records {
+		records[i] = newPairRecord(fmt.Sprintf("a %d", i), fmt.Sprintf("b %d", i), differ)
+		records[i].Row = i + 1
+	}
+	return records
+}
+
+func TestDiffConcurrently(t *testing.T) {
+	var (
+		errRead  = errors.New("read failed")
+		errWrite = errors.New("write failed")
+	)
+	tests := map[string]struct {
+		workers int
+		records int
+		// failRead and failWrite are the 1-indexed record that fails, or 0 to succeed.
+		failRead  int
+		failWrite int
+		written   int
+		err       error
+	}{
+		"Single worker": {
+			workers: 1,
+			records: 20,
+			written: 20,
+		},
+		"Many workers": {
+			workers: 8,
+			records: 200,
+			written: 200,
+		},
+		"More workers than records": {
+			workers: 8,
+			records: 3,
+			written: 3,
+		},
+		"No records": {
+			workers: 4,
+		},
+		"Read error": {
+			workers:  4,
+			records:  50,
+			failRead: 30,
+			written:  29,
+			err:      errRead,
+		},
+		"Write error": {
+			workers:   4,
+			records:   50,
+			failWrite: 10,
+			written:   9,
+			err:       errWrite,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				records = testRecords(tc.records)
+				source  = sliceSource(records)
+				read    int
+				written []int
+			)
+			next := func() (*diffRecord, error) {
+				read++
+				if read == tc.failRead {
+					return nil, errRead
+				}
+				return source()
+			}
+			write := func(r *diffRecord) error {
+				if r.Row == tc.failWrite {
+					return errWrite
+				}
+				assert.NotNil(t, r.diff, "Record %d should be diffed before it's written", r.Row)
+				written = append(written, r.Row)
+				return nil
+			}
+
+			err := diffConcurrently(tc.workers, next, write)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, written, tc.written)
+			for i, row := range written {
+				assert.Equal(t, i+1, row, "Records should be written in order")
+			}
+		})
+	}
+}
+
+func TestPageFileName(t *testing.T) {
+	tests := map[string]struct {
+		outFile string
+		page    int
+		name    string
+	}{
+		"First page":        {outFile: "out/report.html", page: 1, name: "out/report.html"},
+		"Second page":       {outFile: "out/report.html", page: 2, name: "out/report-2.html"},
+		"Without extension": {outFile: "report", page: 3, name: "report-3"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.name, pageFileName(tc.outFile, tc.page))
+		})
+	}
+}
+
+func TestHTMLReportWriter_Pagination(t *testing.T) {
+	tests := map[string]struct {
+		records  int
+		pageSize int
+		// pages holds the number of records expected on each page.
+		pages []int
+	}{
+		"Single page": {
+			records: 5,
+			pages:   []int{5},
+		},
+		"Even pages": {
+			records:  4,
+			pageSize: 2,
+			pages:    []int{2, 2},
+		},
+		"Partial last page": {
+			records:  5,
+			pageSize: 2,
+			pages:    []int{2, 2, 1},
+		},
+		"No records": {
+			pageSize: 2,
+			pages:    []int{0},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			outFile := filepath.Join(t.TempDir(), "index.html")
+			w, err := newHTMLReportWriter(reportPage{Columns: []string{"A", "B"}, Comparisons: []string{"Difference"}}, outFile, tc.pageSize)
+			require.NoError(t, err)
+			templ = defaultTempl
+			_, err = generateReport(&Config{Workers: 1}, w, sliceSource(testRecords(tc.records)))
+			require.NoError(t, err)
+
+			for i, count := range tc.pages {
+				page := i + 1
+				data, err := os.ReadFile(pageFileName(outFile, page))
+				require.NoError(t, err, "Page %d should exist", page)
+				html := string(data)
+
+				assert.Equal(t, count, strings.Count(html, `<tr class="record`), "Page %d record count", page)
+				assert.Equal(t, page > 1, strings.Contains(html, fmt.Sprintf(`href="%s"`, filepath.Base(pageFileName(outFile, page-1)))), "Page %d previous link", page)
+				assert.Equal(t, page < len(tc.pages), strings.Contains(html, fmt.Sprintf(`href="%s"`, filepath.Base(pageFileName(outFile, page+1)))), "Page %d next link", page)
+				assert.Equal(t, page == len(tc.pages), strings.Contains(html, `id="summary"`), "Only the last page should have the summary")
+			}
+			_, err = os.Stat(pageFileName(outFile, len(tc.pages)+1))
+			assert.ErrorIs(t, err, os.ErrNotExist, "There should be no extra pages")
+		})
+	}
+}
+
+func TestNewHTMLReportWriter_PaginatedStdout(t *testing.T) {
+	_, err := newHTMLReportWriter(reportPage{}, "-", 10)
+	assert.Error(t, err)
+}
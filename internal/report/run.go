@@ -0,0 +1,180 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	flag "github.com/spf13/pflag"
+	"log"
+	"os"
+)
+
+// usageError is an error in the given options, which is reported with usage information.
+type usageError struct {
+	error
+}
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// Main runs the report command with the given name and arguments, and returns the exit code.
+// The name is used in usage information, so the command can run on its own or as a subcommand.
+func Main(name string, cmdline []string) int {
+	config := new(Config)
+	flags := setupFlags(name, config)
+	if err := flags.Parse(cmdline); err != nil {
+		log.Println(err)
+		flags.Usage()
+		return 1
+	}
+	if config.HelpRequested {
+		flags.Usage()
+		return 0
+	}
+	if len(config.ConfigFile) == 0 {
+		config.ConfigFile = os.Getenv(envName("config"))
+	}
+
+	var file *settingsFile
+	if len(config.ConfigFile) > 0 {
+		var err error
+		file, err = loadSettingsFile(config.ConfigFile)
+		if err != nil {
+			log.Println(err)
+			return config.errorCode()
+		}
+	}
+	jobs, err := file.selectJobs(config.Jobs)
+	if err != nil {
+		log.Println(err)
+		return config.errorCode()
+	}
+
+	var (
+		code     = ExitIdentical
+		settings []map[string]any
+	)
+	for _, job := range jobs {
+		jobConfig, jobFlags, args, err := loadConfig(name, cmdline, file, job)
+		if err != nil {
+			log.Println(err)
+			return config.errorCode()
+		}
+		if config.PrintConfig {
+			settings = append(settings, effectiveSettings(job.Name, jobFlags, args))
+			continue
+		}
+		if len(job.Name) > 0 {
+			log.Printf("Running job '%s'...", job.Name)
+		}
+		code = max(code, runJob(jobConfig, jobFlags, args))
+	}
+	if config.PrintConfig {
+		if err := printConfig(os.Stdout, settings); err != nil {
+			log.Println(err)
+			return config.errorCode()
+		}
+	}
+	return code
+}
+
+// runJob runs a single comparison, and returns the exit code for it.
+func runJob(config *Config, flags *flag.FlagSet, args []string) int {
+	summary, err := run(config, flags, args)
+	if err != nil {
+		log.Println(err)
+		var usage usageError
+		if errors.As(err, &usage) {
+			flags.Usage()
+		}
+		return config.errorCode()
+	}
+	code, reason := config.checkSummary(summary)
+	if code != ExitIdentical {
+		log.Println(reason)
+	}
+	return code
+}
+
+// run validates the configuration and runs the selected kind of comparison.
+func run(config *Config, flags *flag.FlagSet, args []string) (*reportSummary, error) {
+	config.ALabelSet = flags.Changed("header-a")
+	config.BLabelSet = flags.Changed("header-b")
+	if err := config.DiffOptions.Validate(); err != nil {
+		return nil, usageError{err}
+	}
+	if config.Workers < 1 {
+		return nil, usageErrorf("worker count %d is below the lower bound of 1", config.Workers)
+	}
+	if config.MinSimilarity < 0 || config.MinSimilarity > 1 {
+		return nil, usageErrorf("minimum similarity %g is not between 0 and 1", config.MinSimilarity)
+	}
+	if config.RowSimilarity < 0 || config.RowSimilarity > 1 {
+		return nil, usageErrorf("similarity threshold %g is not between 0 and 1", config.RowSimilarity)
+	}
+	if err := validateOutputFormat(config.OutputFormat); err != nil {
+		return nil, usageError{err}
+	}
+
+	templ = defaultTempl
+	if len(config.Template) > 0 {
+		custom, err := loadTemplate(config.Template)
+		if err != nil {
+			return nil, err
+		}
+		templ = custom
+	}
+
+	switch {
+	case config.Dirs:
+		if config.OutputFormat != OutputHTML {
+			return nil, fmt.Errorf("directory comparison only supports HTML output")
+		}
+		if len(args) < 2 {
+			return nil, usageErrorf("missing old and new directory arguments")
+		}
+		return runDirGeneration(config, args[0], args[1])
+	case config.Tables:
+		if config.OutputFormat == OutputCSV {
+			return nil, fmt.Errorf("table comparison doesn't support CSV output")
+		}
+		if len(args) < 2 {
+			return nil, usageErrorf("missing old and new table arguments")
+		}
+		return runTableGeneration(config, args[0], args[1])
+	case config.LineFiles:
+		if config.OutputFormat == OutputCSV {
+			return nil, fmt.Errorf("two file comparison doesn't support CSV output")
+		}
+		if len(args) < 2 {
+			return nil, usageErrorf("missing old and new file arguments")
+		}
+		return runLineFileGeneration(config, args[0], args[1])
+	case len(config.InFile) > 0:
+		summary, err := runFileGeneration(config)
+		if err != nil {
+			return nil, usageError{err}
+		}
+		return summary, nil
+	}
+
+	if len(args) < 2 {
+		return nil, usageErrorf("missing A and B sample arguments")
+	}
+	if config.OutputFormat == OutputCSV {
+		return nil, fmt.Errorf("single pair diff doesn't support CSV output")
+	}
+	r := newPairRecord(args[0], args[1], newDiffer(config))
+	if config.OutputFormat == OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		if err := enc.Encode(newJSONRecord(r)); err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Print(r.DiffHTML())
+	}
+	summary := newReportSummary()
+	summary.AddRecord(r)
+	return summary, nil
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"fmt"
+	"github.com/drognisep/linediff"
+)
+
+// similarityBucket counts the records with a similarity within a range.
+type similarityBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+	// Percent is the proportion of all records in this bucket, from 0 to 100.
+	Percent float64 `json:"percent"`
+}
+
+// reportSummary holds the statistics for all records in a report.
+type reportSummary struct {
+	Records        int     `json:"records"`
+	Changed        int     `json:"changed"`
+	Unchanged      int     `json:"unchanged"`
+	TokensAdded    int     `json:"tokensAdded"`
+	TokensRemoved  int     `json:"tokensRemoved"`
+	MeanSimilarity float64 `json:"meanSimilarity"`
+	// MinSimilarity is the lowest similarity of any record.
+	MinSimilarity float64            `json:"minSimilarity"`
+	Distribution  []similarityBucket `json:"distribution"`
+	// Comparisons holds a summary per comparison, when records have more than one.
+	Comparisons []*reportSummary `json:"comparisons,omitempty"`
+	Label       string           `json:"label,omitempty"`
+	// Statuses counts the rows of a keyed table comparison by status.
+	Statuses      map[string]int `json:"statuses,omitempty"`
+	similaritySum float64
+}
+
+func newReportSummary() *reportSummary {
+	s := &reportSummary{
+		Distribution:   make([]similarityBucket, 11),
+		MeanSimilarity: 1,
+		MinSimilarity:  1,
+	}
+	for i := 0; i < 10; i++ {
+		s.Distribution[i].Label = fmt.Sprintf("%d-%d%%", i*10, i*10+9)
+	}
+	s.Distribution[10].Label = "100%"
+	return s
+}
+
+// AddRecord includes the combined stats of a record in the summary, as well as the stats for each comparison if there's more than one.
+// The combined stats are returned.
+func (s *reportSummary) AddRecord(r *diffRecord) linediff.DiffStats {
+	stats := r.Stats()
+	s.Add(stats)
+	if len(r.Status) > 0 {
+		if s.Statuses == nil {
+			s.Statuses = map[string]int{}
+		}
+		s.Statuses[r.Status]++
+	}
+	if len(r.Comparisons) <= 1 {
+		return stats
+	}
+	for i, c := range r.Comparisons {
+		if i >= len(s.Comparisons) {
+			cs := newReportSummary()
+			cs.Label = c.Label
+			s.Comparisons = append(s.Comparisons, cs)
+		}
+		s.Comparisons[i].Add(c.Stats())
+	}
+	return stats
+}
+
+// Add includes the stats for a record in the summary.
+func (s *reportSummary) Add(stats linediff.DiffStats) {
+	s.Records++
+	if stats.Changed() {
+		s.Changed++
+	} else {
+		s.Unchanged++
+	}
+	s.TokensAdded += stats.Added
+	s.TokensRemoved += stats.Removed
+	s.similaritySum += stats.Similarity
+	s.MeanSimilarity = s.similaritySum / float64(s.Records)
+	s.MinSimilarity = min(s.MinSimilarity, stats.Similarity)
+
+	bucket := 10
+	if stats.Changed() {
+		// A changed record is never shown as 100% similar, even if rounding would suggest it.
+		bucket = min(int(stats.Similarity*10), 9)
+	}
+	s.Distribution[bucket].Count++
+	for i := range s.Distribution {
+		s.Distribution[i].Percent = 100 * float64(s.Distribution[i].Count) / float64(s.Records)
+	}
+}
+
+// merge includes the record totals of another summary, without its per comparison summaries or status counts.
+func (s *reportSummary) merge(other *reportSummary) {
+	if other.Records == 0 {
+		return
+	}
+	s.Records += other.Records
+	s.Changed += other.Changed
+	s.Unchanged += other.Unchanged
+	s.TokensAdded += other.TokensAdded
+	s.TokensRemoved += other.TokensRemoved
+	s.similaritySum += other.similaritySum
+	s.MeanSimilarity = s.similaritySum / float64(s.Records)
+	s.MinSimilarity = min(s.MinSimilarity, other.MinSimilarity)
+	for i := range s.Distribution {
+		s.Distribution[i].Count += other.Distribution[i].Count
+		s.Distribution[i].Percent = 100 * float64(s.Distribution[i].Count) / float64(s.Records)
+	}
+}
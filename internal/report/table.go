@@ -0,0 +1,407 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"log"
+	"strconv"
+	"strings"
+)
+
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// keySeparator joins the values of multiple key columns, and can't reasonably appear in CSV data.
+const keySeparator = "\x1f"
+
+// tableSchema describes the column changes between two versions of a table.
+type tableSchema struct {
+	OldColumns []string `json:"oldColumns"`
+	NewColumns []string `json:"newColumns"`
+	Added      []string `json:"added,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+	// Reordered is true if the columns in both tables aren't in the same relative order.
+	Reordered bool `json:"reordered"`
+}
+
+// Changed returns true if any columns were added, removed, or reordered.
+func (s *tableSchema) Changed() bool {
+	return len(s.Added) > 0 || len(s.Removed) > 0 || s.Reordered
+}
+
+// table is a CSV or TSV file read into memory, so rows can be joined by key.
+type table struct {
+	header []string
+	rows   [][]string
+	// offset is the number of rows before the first data row, which is 1 if there's a header row.
+	offset int
+}
+
+// readTable reads the named CSV or TSV file.
+// If there's no header row, then columns are labeled like 'Column 0' and matched by position.
+// With 'json-cells', cells holding a JSON object or array are normalized, so they're joined and diffed by their content.
+func readTable(config *Config, name string) (*table, error) {
+	in, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	records, err := readAllFields(newFieldReader(config, in))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table '%s': %w", name, err)
+	}
+	if len(records) > 0 {
+		trimBOM(records[0])
+	}
+	t := &table{rows: records}
+	if config.SkipFirstRow && len(records) > 0 {
+		t.header, t.rows = records[0], records[1:]
+		t.offset = 1
+	}
+	if t.header == nil && len(t.rows) > 0 {
+		for col := range t.rows[0] {
+			t.header = append(t.header, fmt.Sprintf("Column %d", col))
+		}
+	}
+	if len(t.header) == 0 {
+		return nil, fmt.Errorf("table '%s' has no columns", name)
+	}
+	if config.JSONCells {
+		for _, row := range t.rows {
+			for col, value := range row {
+				row[col] = normalizeJSONCell(value)
+			}
+		}
+	}
+	return t, nil
+}
+
+// cell returns the value of a column in a row, or an empty string if the row doesn't have the column.
+func cell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+// keys returns the key of each row, made up of the values of the key columns.
+func (t *table) keys(name string, keyCols []int) ([]string, error) {
+	var (
+		keys = make([]string, len(t.rows))
+		seen = map[string]int{}
+	)
+	for i, row := range t.rows {
+		values := make([]string, len(keyCols))
+		for j, col := range keyCols {
+			values[j] = cell(row, col)
+		}
+		key := strings.Join(values, keySeparator)
+		if prev, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%w: %s in table '%s' at rows %d and %d", ErrDuplicateKey, quoteList(values), name, prev+t.offset+1, i+t.offset+1)
+		}
+		seen[key] = i
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// columnIDs returns a unique identity for each header, so repeated header names are matched by their occurrence.
+func columnIDs(header []string) []string {
+	var (
+		ids  = make([]string, len(header))
+		seen = map[string]int{}
+	)
+	for i, name := range header {
+		ids[i] = name + keySeparator + strconv.Itoa(seen[name])
+		seen[name]++
+	}
+	return ids
+}
+
+// joinOrder pairs up elements of a and b with the same identity, which must be unique within each sequence.
+// Pairs follow the order of b, with elements that are only in a placed before the next paired element that followed them in a.
+// If any paired elements are in a different relative order, then moved is true.
+func joinOrder(a, b []string) (pairs []linediff.Pair, moved bool) {
+	inA := make(map[string]int, len(a))
+	for i, id := range a {
+		inA[id] = i
+	}
+	inB := make(map[string]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+
+	pairs = make([]linediff.Pair, 0, max(len(a), len(b)))
+	var (
+		added   []linediff.Pair
+		next    int
+		lastA   = -1
+		removed = func(end int) {
+			for ; next < end; next++ {
+				if !inB[a[next]] {
+					pairs = append(pairs, linediff.Pair{A: next, B: -1})
+				}
+			}
+		}
+	)
+	for j, id := range b {
+		i, ok := inA[id]
+		if !ok {
+			added = append(added, linediff.Pair{A: -1, B: j})
+			continue
+		}
+		if i < lastA {
+			moved = true
+		}
+		lastA = i
+		removed(i)
+		pairs = append(pairs, added...)
+		added = added[:0]
+		pairs = append(pairs, linediff.Pair{A: i, B: j})
+		next = max(next, i+1)
+	}
+	removed(len(a))
+	return append(pairs, added...), moved
+}
+
+// tableColumn is a column in the union of two tables' columns, with an index of -1 in the table that doesn't have it.
+type tableColumn struct {
+	Label    string
+	Old, New int
+}
+
+// alignColumns matches the columns of two tables by header name.
+func alignColumns(oldHeader, newHeader []string) ([]tableColumn, *tableSchema) {
+	schema := &tableSchema{
+		OldColumns: oldHeader,
+		NewColumns: newHeader,
+	}
+	var (
+		pairs   []linediff.Pair
+		columns []tableColumn
+	)
+	pairs, schema.Reordered = joinOrder(columnIDs(oldHeader), columnIDs(newHeader))
+	for _, p := range pairs {
+		switch {
+		case p.Matched():
+			columns = append(columns, tableColumn{Label: newHeader[p.B], Old: p.A, New: p.B})
+		case p.B < 0:
+			schema.Removed = append(schema.Removed, oldHeader[p.A])
+			columns = append(columns, tableColumn{Label: oldHeader[p.A] + " (removed)", Old: p.A, New: -1})
+		default:
+			schema.Added = append(schema.Added, newHeader[p.B])
+			columns = append(columns, tableColumn{Label: newHeader[p.B] + " (added)", Old: -1, New: p.B})
+		}
+	}
+	return columns, schema
+}
+
+// resolveKey resolves the key columns in a table's header.
+func resolveKey(header []string, key []string) ([]int, error) {
+	cols := make([]int, len(key))
+	for i, column := range key {
+		col, err := resolveColumn(header, column)
+		if err != nil {
+			return nil, err
+		}
+		if col >= len(header) {
+			return nil, fmt.Errorf("%w: key column index '%d' is out of bounds for %d columns", ErrInvalidColIndex, col, len(header))
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+// tableDiff is the result of comparing two tables.
+type tableDiff struct {
+	records []*diffRecord
+	// keyLabels are the new table's header names of the key columns.
+	keyLabels []string
+	columns   []tableColumn
+	schema    *tableSchema
+}
+
+// joinRows pairs the rows of two tables with the same key, returning the pairs and the resolved key columns of each table.
+func joinRows(key []string, oldTable, newTable *table) (pairs []linediff.Pair, oldKeyCols, newKeyCols []int, err error) {
+	oldKeyCols, err = resolveKey(oldTable.header, key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("old table: %w", err)
+	}
+	newKeyCols, err = resolveKey(newTable.header, key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("new table: %w", err)
+	}
+	oldKeys, err := oldTable.keys("old", oldKeyCols)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	newKeys, err := newTable.keys("new", newKeyCols)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pairs, _ = joinOrder(oldKeys, newKeys)
+	return pairs, oldKeyCols, newKeyCols, nil
+}
+
+// alignRows aligns the rows of two tables without a key, using a sequence diff over whole rows.
+// Rows that aren't identical are paired as changed if the token diff of their cells in common columns is at least threshold similar,
+// otherwise they're treated as a removal and an addition.
+func alignRows(oldTable, newTable *table, columns []tableColumn, threshold float64, differ *linediff.Differ) []linediff.Pair {
+	var common []tableColumn
+	for _, col := range columns {
+		if col.Old >= 0 && col.New >= 0 {
+			common = append(common, col)
+		}
+	}
+	rowIDs := func(rows [][]string, old bool) []string {
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			values := make([]string, len(common))
+			for j, col := range common {
+				if old {
+					values[j] = cell(row, col.Old)
+				} else {
+					values[j] = cell(row, col.New)
+				}
+			}
+			ids[i] = strings.Join(values, keySeparator)
+		}
+		return ids
+	}
+	pairs := linediff.AlignStrings(rowIDs(oldTable.rows, true), rowIDs(newTable.rows, false))
+	return linediff.PairSimilar(pairs, threshold, func(a, b int) float64 {
+		stats := make([]linediff.DiffStats, len(common))
+		for i, col := range common {
+			stats[i] = differ.Diff(cell(oldTable.rows[a], col.Old), cell(newTable.rows[b], col.New)).Stats()
+		}
+		return linediff.CombineStats(stats...).Similarity
+	})
+}
+
+// diffTables compares the rows of two tables, creating a record per row with a comparison per column.
+// Rows are joined by key if there are key columns, otherwise they're aligned by content with the similarity threshold.
+// Cells in a column that only one table has are compared to themselves when the row is in both tables,
+// so schema changes are reported once instead of marking every row as changed.
+// With jsonCells, matched cells holding structurally equal JSON documents are identical, even if they're written differently.
+func diffTables(key []string, threshold float64, jsonCells bool, oldTable, newTable *table, differ *linediff.Differ) (*tableDiff, error) {
+	var (
+		td                     = new(tableDiff)
+		rowPairs               []linediff.Pair
+		oldKeyCols, newKeyCols []int
+		err                    error
+	)
+	td.columns, td.schema = alignColumns(oldTable.header, newTable.header)
+	if len(key) > 0 {
+		rowPairs, oldKeyCols, newKeyCols, err = joinRows(key, oldTable, newTable)
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range newKeyCols {
+			td.keyLabels = append(td.keyLabels, newTable.header[col])
+		}
+	} else {
+		rowPairs = alignRows(oldTable, newTable, td.columns, threshold, differ)
+	}
+	td.records = make([]*diffRecord, 0, len(rowPairs))
+	for _, p := range rowPairs {
+		var (
+			oldRow, newRow []string
+			keyRow         []string
+			keyCols        []int
+			r              = new(diffRecord)
+		)
+		if p.A >= 0 {
+			oldRow, keyRow, keyCols = oldTable.rows[p.A], oldTable.rows[p.A], oldKeyCols
+			r.LineA = p.A + oldTable.offset + 1
+		}
+		if p.B >= 0 {
+			newRow, keyRow, keyCols = newTable.rows[p.B], newTable.rows[p.B], newKeyCols
+			r.LineB = p.B + newTable.offset + 1
+		}
+		for _, col := range keyCols {
+			r.Values = append(r.Values, cell(keyRow, col))
+		}
+
+		switch {
+		case p.A < 0:
+			r.Status = StatusAdded
+		case p.B < 0:
+			r.Status = StatusRemoved
+		default:
+			r.Status = StatusIdentical
+		}
+		for _, col := range td.columns {
+			a, b := cell(oldRow, col.Old), cell(newRow, col.New)
+			if p.Matched() {
+				if col.Old < 0 {
+					a = b
+				} else if col.New < 0 {
+					b = a
+				}
+				if a != b && jsonCells && equalJSONCells(a, b) {
+					a = b
+				}
+				if a != b {
+					r.Status = StatusChanged
+				}
+			}
+			r.addComparison(a, b, col.Label, differ)
+		}
+		td.records = append(td.records, r)
+	}
+	return td, nil
+}
+
+// runTableGeneration compares two versions of a table, joining rows by the configured key columns or aligning them by content.
+func runTableGeneration(config *Config, oldFile, newFile string) (*reportSummary, error) {
+	if config.Format != FormatCSV && config.Format != FormatTSV {
+		return nil, fmt.Errorf("%w: table comparison requires %s or %s input", ErrInvalidFormat, FormatCSV, FormatTSV)
+	}
+
+	log.Println("Reading tables...")
+	oldTable, err := readTable(config, oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newTable, err := readTable(config, newFile)
+	if err != nil {
+		return nil, err
+	}
+	td, err := diffTables(config.Key, config.RowSimilarity, config.JSONCells, oldTable, newTable, newDiffer(config))
+	if err != nil {
+		return nil, err
+	}
+
+	page := reportPage{
+		FileName:    fmt.Sprintf("%s → %s", oldFile, newFile),
+		HeaderA:     config.ALabel,
+		HeaderB:     config.BLabel,
+		LineNumbers: true,
+		Columns:     td.keyLabels,
+		Schema:      td.schema,
+	}
+	if !config.ALabelSet {
+		page.HeaderA = "Old"
+	}
+	if !config.BLabelSet {
+		page.HeaderB = "New"
+	}
+	for _, col := range td.columns {
+		page.Comparisons = append(page.Comparisons, col.Label)
+	}
+
+	w, err := newReportWriter(config, page, config.OutFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Generating report...")
+	summary, err := generateReport(config, w, sliceSource(td.records))
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Done")
+	return summary, nil
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	"github.com/drognisep/linediff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestJoinOrder(t *testing.T) {
+	tests := map[string]struct {
+		a, b  []string
+		pairs []linediff.Pair
+		moved bool
+	}{
+		"Same": {
+			a:     []string{"a", "b"},
+			b:     []string{"a", "b"},
+			pairs: []linediff.Pair{{A: 0, B: 0}, {A: 1, B: 1}},
+		},
+		"Added and removed": {
+			a:     []string{"a", "x", "b"},
+			b:     []string{"a", "b", "y"},
+			pairs: []linediff.Pair{{A: 0, B: 0}, {A: 1, B: -1}, {A: 2, B: 1}, {A: -1, B: 2}},
+		},
+		"Removals before additions": {
+			a:     []string{"a", "x", "b"},
+			b:     []string{"a", "y", "b"},
+			pairs: []linediff.Pair{{A: 0, B: 0}, {A: 1, B: -1}, {A: -1, B: 1}, {A: 2, B: 2}},
+		},
+		"Reordered": {
+			a:     []string{"a", "b", "c"},
+			b:     []string{"c", "a", "b"},
+			pairs: []linediff.Pair{{A: 2, B: 0}, {A: 0, B: 1}, {A: 1, B: 2}},
+			moved: true,
+		},
+		"Reordered with removal": {
+			a:     []string{"a", "x", "b", "c"},
+			b:     []string{"b", "a", "c"},
+			pairs: []linediff.Pair{{A: 1, B: -1}, {A: 2, B: 0}, {A: 0, B: 1}, {A: 3, B: 2}},
+			moved: true,
+		},
+		"Disjoint": {
+			a:     []string{"a"},
+			b:     []string{"b"},
+			pairs: []linediff.Pair{{A: 0, B: -1}, {A: -1, B: 0}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pairs, moved := joinOrder(tc.a, tc.b)
+			assert.Equal(t, tc.pairs, pairs)
+			assert.Equal(t, tc.moved, moved)
+		})
+	}
+}
+
+func TestJoinOrder_Large(t *testing.T) {
+	const n = 50000
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+		b[i] = strconv.Itoa(n - i - 1)
+	}
+	pairs, moved := joinOrder(a, b)
+	assert.True(t, moved)
+	require.Len(t, pairs, n)
+	for j, p := range pairs {
+		assert.Equal(t, linediff.Pair{A: n - j - 1, B: j}, p)
+	}
+}
+
+func TestAlignColumns(t *testing.T) {
+	columns, schema := alignColumns([]string{"id", "name", "old", "name"}, []string{"name", "id", "new", "name"})
+	assert.Equal(t, []tableColumn{
+		{Label: "name", Old: 1, New: 0},
+		{Label: "id", Old: 0, New: 1},
+		{Label: "old (removed)", Old: 2, New: -1},
+		{Label: "new (added)", Old: -1, New: 2},
+		{Label: "name", Old: 3, New: 3},
+	}, columns)
+	assert.Equal(t, []string{"new"}, schema.Added)
+	assert.Equal(t, []string{"old"}, schema.Removed)
+	assert.True(t, schema.Reordered)
+}
+
+func TestDiffTables(t *testing.T) {
+	oldTable := &table{
+		header: []string{"id", "name", "city"},
+		rows: [][]string{
+			{"1", "Ann", "Oslo"},
+			{"2", "Bob", "Rome"},
+			{"3", "Cy", "Lima"},
+		},
+		offset: 1,
+	}
+	newTable := &table{
+		header: []string{"id", "name", "city", "zip"},
+		rows: [][]string{
+			{"3", "Cy", "Lima", "100"},
+			{"1", "Ann", "Bergen", "200"},
+			{"4", "Dee", "Kyiv", "300"},
+		},
+		offset: 1,
+	}
+	type row struct {
+		status       string
+		lineA, lineB int
+		values       []string
+	}
+	tests := map[string]struct {
+		key       []string
+		threshold float64
+		rows      []row
+		err       error
+	}{
+		"Keyed": {
+			key: []string{"id"},
+			rows: []row{
+				{status: StatusRemoved, lineA: 3, values: []string{"2"}},
+				{status: StatusIdentical, lineA: 4, lineB: 2, values: []string{"3"}},
+				{status: StatusChanged, lineA: 2, lineB: 3, values: []string{"1"}},
+				{status: StatusAdded, lineB: 4, values: []string{"4"}},
+			},
+		},
+		"Keyed by name and position": {
+			key: []string{"NAME", "0"},
+			rows: []row{
+				{status: StatusRemoved, lineA: 3, values: []string{"Bob", "2"}},
+				{status: StatusIdentical, lineA: 4, lineB: 2, values: []string{"Cy", "3"}},
+				{status: StatusChanged, lineA: 2, lineB: 3, values: []string{"Ann", "1"}},
+				{status: StatusAdded, lineB: 4, values: []string{"Dee", "4"}},
+			},
+		},
+		"Unknown key": {
+			key: []string{"email"},
+			err: ErrUnknownColumn,
+		},
+		"Unkeyed": {
+			threshold: 0.5,
+			rows: []row{
+				{status: StatusRemoved, lineA: 2},
+				{status: StatusRemoved, lineA: 3},
+				{status: StatusIdentical, lineA: 4, lineB: 2},
+				{status: StatusAdded, lineB: 3},
+				{status: StatusAdded, lineB: 4},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			td, err := diffTables(tc.key, tc.threshold, false, oldTable, newTable, linediff.NewDiffer(linediff.SplitSpaces))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, td.records, len(tc.rows))
+			for i, r := range td.records {
+				assert.Equal(t, tc.rows[i], row{status: r.Status, lineA: r.LineA, lineB: r.LineB, values: r.Values}, "Row %d", i)
+				assert.Len(t, r.Comparisons, len(td.columns))
+			}
+			assert.Equal(t, []string{"zip"}, td.schema.Added)
+		})
+	}
+}
+
+func TestDiffTables_DuplicateKey(t *testing.T) {
+	tbl := &table{
+		header: []string{"id"},
+		rows:   [][]string{{"1"}, {"1"}},
+		offset: 1,
+	}
+	_, err := diffTables([]string{"id"}, 0.5, false, tbl, tbl, linediff.NewDiffer(linediff.SplitSpaces))
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+}
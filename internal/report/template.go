@@ -0,0 +1,390 @@
+package report
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/drognisep/linediff"
+	"github.com/drognisep/linediff/internal/cli"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("invalid template")
+	//go:embed output.gohtml
+	templText string
+	// templateFuncs are the helper functions available to both the default and custom report templates.
+	templateFuncs = template.FuncMap{
+		"plusOne": func(i int) int {
+			return i + 1
+		},
+		"percent": func(f float64) string {
+			return fmt.Sprintf("%.1f%%", f*100)
+		},
+		"changes": func(stats linediff.DiffStats) int {
+			return stats.Added + stats.Removed
+		},
+		"segments": func(r *diffRecord) []linediff.Segment {
+			return r.Diff().Segments()
+		},
+		"segmentHTML": func(seg linediff.Segment) template.HTML {
+			return template.HTML(cli.SegmentHTML(seg))
+		},
+		"wordDiff": func(r *diffRecord) string {
+			return r.Diff().WordDiff()
+		},
+	}
+	// defaultTempl is the embedded report template, and templ is the template in use, which may be a custom template.
+	defaultTempl = template.Must(template.New("html").Funcs(templateFuncs).Parse(templText))
+	templ        = defaultTempl
+	// requiredTemplates must be defined by a custom report template, and are executed with these types of data.
+	requiredTemplates = []string{"header", "row", "footer"}
+	templateData      = map[string]reflect.Type{
+		"header": reflect.TypeOf(reportPage{}),
+		"row":    reflect.TypeOf(reportRow{}),
+		"footer": reflect.TypeOf(reportPage{}),
+	}
+	// templateFields are the field and method names that may be referenced by a template that none of the required templates use,
+	// so the type of its data isn't known.
+	templateFields = knownFields(
+		reportPage{},
+		reportRow{},
+		&diffRecord{},
+		&comparison{},
+		&tableSchema{},
+		&reportSummary{},
+		similarityBucket{},
+		linediff.DiffStats{},
+		linediff.Segment{},
+		linediff.Tag(0),
+	)
+)
+
+// knownFields collects the exported field and method names of the values' types.
+func knownFields(values ...any) map[string]bool {
+	names := map[string]bool{}
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		for i := 0; i < t.NumMethod(); i++ {
+			names[t.Method(i).Name] = true
+		}
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				names[t.Field(i).Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// loadTemplate parses and validates a custom report template.
+func loadTemplate(path string) (*template.Template, error) {
+	t, err := template.New("custom").Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if err := validateTemplate(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// validateTemplate ensures that the required templates are defined, and that only known fields are referenced.
+// Fields are checked against the type of the data in scope, starting with the data of each required template, and following
+// range, with, variables, helper functions, and templates called with {{template}}.
+func validateTemplate(t *template.Template) error {
+	for _, name := range requiredTemplates {
+		if t.Lookup(name) == nil {
+			return fmt.Errorf("%w: the '%s' template must be defined", ErrInvalidTemplate, name)
+		}
+	}
+	c := &templateChecker{
+		templ:   t,
+		checked: map[string]bool{},
+		reached: map[string]bool{},
+		unknown: map[string]bool{},
+	}
+	for _, name := range requiredTemplates {
+		c.checkTemplate(name, templateData[name])
+	}
+	for _, defined := range t.Templates() {
+		if defined.Tree == nil || c.reached[defined.Name()] {
+			continue
+		}
+		walkFields(defined.Tree.Root, func(name string) {
+			if !templateFields[name] {
+				c.unknown[fmt.Sprintf("%s in '%s'", name, defined.Name())] = true
+			}
+		})
+	}
+	if unknown := c.unknown; len(unknown) > 0 {
+		var names []string
+		for name := range unknown {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%w: unknown field(s) referenced: %s", ErrInvalidTemplate, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// templateChecker checks the fields referenced by templates against the type of the data in scope.
+// A nil type is unknown, like the result of most builtin functions, so fields referenced from it aren't checked.
+type templateChecker struct {
+	templ *template.Template
+	// checked holds each template name and data type that's been checked, and reached holds each template name.
+	checked map[string]bool
+	reached map[string]bool
+	// unknown holds each unknown field, and the template it's referenced in.
+	unknown map[string]bool
+}
+
+// templateScope is the data and variables in scope at a point in a template.
+type templateScope struct {
+	name string
+	dot  reflect.Type
+	vars map[string]reflect.Type
+}
+
+// branch returns a copy of the scope with its own variables, for a nested list.
+func (s templateScope) branch(dot reflect.Type) templateScope {
+	vars := make(map[string]reflect.Type, len(s.vars))
+	for name, t := range s.vars {
+		vars[name] = t
+	}
+	return templateScope{name: s.name, dot: dot, vars: vars}
+}
+
+func (c *templateChecker) checkTemplate(name string, dot reflect.Type) {
+	key := fmt.Sprintf("%s %v", name, dot)
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+	c.reached[name] = true
+	defined := c.templ.Lookup(name)
+	if defined == nil || defined.Tree == nil {
+		return
+	}
+	c.walk(defined.Tree.Root, templateScope{name: name, dot: dot, vars: map[string]reflect.Type{"$": dot}})
+}
+
+func (c *templateChecker) walk(node parse.Node, s templateScope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, s)
+		}
+	case *parse.ActionNode:
+		c.declare(n.Pipe, c.pipeType(n.Pipe, s), s)
+	case *parse.IfNode:
+		c.declare(n.Pipe, c.pipeType(n.Pipe, s), s)
+		c.walk(n.List, s.branch(s.dot))
+		c.walk(n.ElseList, s.branch(s.dot))
+	case *parse.WithNode:
+		t := c.pipeType(n.Pipe, s)
+		inner := s.branch(t)
+		c.declare(n.Pipe, t, inner)
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, s.branch(s.dot))
+	case *parse.RangeNode:
+		key, elem := rangeTypes(c.pipeType(n.Pipe, s))
+		inner := s.branch(elem)
+		switch len(n.Pipe.Decl) {
+		case 1:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = key
+			inner.vars[n.Pipe.Decl[1].Ident[0]] = elem
+		}
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, s.branch(s.dot))
+	case *parse.TemplateNode:
+		var dot reflect.Type
+		if n.Pipe != nil {
+			dot = c.pipeType(n.Pipe, s)
+		}
+		c.checkTemplate(n.Name, dot)
+	}
+}
+
+// declare sets the type of any variables declared by the pipeline.
+func (c *templateChecker) declare(pipe *parse.PipeNode, t reflect.Type, s templateScope) {
+	for _, v := range pipe.Decl {
+		s.vars[v.Ident[0]] = t
+	}
+}
+
+// pipeType checks the fields referenced in a pipeline and returns the type of its result.
+func (c *templateChecker) pipeType(pipe *parse.PipeNode, s templateScope) reflect.Type {
+	var t reflect.Type
+	for _, cmd := range pipe.Cmds {
+		t = c.commandType(cmd, s)
+	}
+	return t
+}
+
+func (c *templateChecker) commandType(cmd *parse.CommandNode, s templateScope) reflect.Type {
+	if len(cmd.Args) == 0 {
+		return nil
+	}
+	for _, arg := range cmd.Args[1:] {
+		c.nodeType(arg, s)
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		if fn, ok := templateFuncs[ident.Ident]; ok {
+			if ft := reflect.TypeOf(fn); ft.NumOut() > 0 {
+				return ft.Out(0)
+			}
+		}
+		return nil
+	}
+	return c.nodeType(cmd.Args[0], s)
+}
+
+func (c *templateChecker) nodeType(node parse.Node, s templateScope) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return s.dot
+	case *parse.FieldNode:
+		return c.fieldType(s.dot, n.Ident, s)
+	case *parse.VariableNode:
+		return c.fieldType(s.vars[n.Ident[0]], n.Ident[1:], s)
+	case *parse.ChainNode:
+		return c.fieldType(c.nodeType(n.Node, s), n.Field, s)
+	case *parse.PipeNode:
+		return c.pipeType(n, s)
+	}
+	return nil
+}
+
+// fieldType returns the type of a chain of fields or methods of t, recording any that t doesn't have.
+func (c *templateChecker) fieldType(t reflect.Type, names []string, s templateScope) reflect.Type {
+	for _, name := range names {
+		if t == nil {
+			return nil
+		}
+		next, ok := memberType(t, name)
+		if !ok {
+			c.unknown[fmt.Sprintf("%s in '%s'", name, s.name)] = true
+			return nil
+		}
+		t = next
+	}
+	return t
+}
+
+// memberType returns the type of the named field or method result of t, or false if t doesn't have it.
+// Map keys and interfaces can't be known, so they have a nil type.
+func memberType(t reflect.Type, name string) (reflect.Type, bool) {
+	method, ok := t.MethodByName(name)
+	if !ok && t.Kind() != reflect.Pointer {
+		method, ok = reflect.PointerTo(t).MethodByName(name)
+	}
+	if ok {
+		if method.Type.NumOut() == 0 {
+			return nil, true
+		}
+		return method.Type.Out(0), true
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if field, ok := t.FieldByName(name); ok && field.IsExported() {
+			return field.Type, true
+		}
+	case reflect.Map:
+		return t.Elem(), t.Key().Kind() == reflect.String
+	case reflect.Interface:
+		return nil, true
+	}
+	return nil, false
+}
+
+// rangeTypes returns the types of the keys and elements of ranging over t.
+func rangeTypes(t reflect.Type) (key, elem reflect.Type) {
+	if t == nil {
+		return nil, nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), t.Elem()
+	case reflect.Map:
+		return t.Key(), t.Elem()
+	case reflect.Int:
+		return nil, t
+	}
+	return nil, nil
+}
+
+// walkFields calls fn with every field name referenced within the node.
+func walkFields(node parse.Node, fn func(name string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkFields(child, fn)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkFields(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkFields(arg, fn)
+		}
+	case *parse.FieldNode:
+		for _, name := range n.Ident {
+			fn(name)
+		}
+	case *parse.ChainNode:
+		walkFields(n.Node, fn)
+		for _, name := range n.Field {
+			fn(name)
+		}
+	case *parse.VariableNode:
+		// The first identifier is the variable name.
+		for _, name := range n.Ident[1:] {
+			fn(name)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(name string)) {
+	walkFields(n.Pipe, fn)
+	walkFields(n.List, fn)
+	walkFields(n.ElseList, fn)
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"html/template"
+	"testing"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	const (
+		header = `{{define "header"}}{{.Width}}{{range .Comparisons}}{{.}}{{end}}{{end}}`
+		row    = `{{define "row"}}{{.Record.LineA}}{{range .Record.Comparisons}}{{.Stats.Added}}{{end}}{{end}}`
+		footer = `{{define "footer"}}{{with .Summary}}{{.Records}}{{end}}{{end}}`
+	)
+	tests := map[string]struct {
+		text    string
+		unknown string
+	}{
+		"Valid": {
+			text: header + row + footer,
+		},
+		"Missing template": {
+			text:    header + row,
+			unknown: "the 'footer' template must be defined",
+		},
+		"Row field in header": {
+			text:    `{{define "header"}}{{.Record.LineA}}{{end}}` + row + footer,
+			unknown: "unknown field(s) referenced: Record in 'header'",
+		},
+		"Comparison field in row": {
+			text:    header + `{{define "row"}}{{.Stats.Added}}{{end}}` + footer,
+			unknown: "unknown field(s) referenced: Stats in 'row'",
+		},
+		"Field in range": {
+			text:    header + `{{define "row"}}{{range .Record.Comparisons}}{{.LineA}}{{end}}{{end}}` + footer,
+			unknown: "unknown field(s) referenced: LineA in 'row'",
+		},
+		"Field in with": {
+			text:    header + row + `{{define "footer"}}{{with .Summary}}{{.Width}}{{end}}{{end}}`,
+			unknown: "unknown field(s) referenced: Width in 'footer'",
+		},
+		"Variables": {
+			text: header + footer +
+				`{{define "row"}}{{$record := .Record}}{{range $i, $c := $record.Comparisons}}{{$i}}{{$c.Stats.Added}}{{$.LineNumbers}}{{end}}{{end}}`,
+		},
+		"Unknown variable field": {
+			text:    header + footer + `{{define "row"}}{{range $c := .Record.Comparisons}}{{$c.Records}}{{end}}{{end}}`,
+			unknown: "unknown field(s) referenced: Records in 'row'",
+		},
+		"Called template": {
+			text:    header + footer + `{{define "row"}}{{template "stats" .Record}}{{end}}{{define "stats"}}{{.Width}}{{end}}`,
+			unknown: "unknown field(s) referenced: Width in 'stats'",
+		},
+		"Unused template": {
+			text:    header + row + footer + `{{define "unused"}}{{.Stats.Added}}{{.Bogus}}{{end}}`,
+			unknown: "unknown field(s) referenced: Bogus in 'unused'",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			templ, err := template.New("custom").Funcs(templateFuncs).Parse(tc.text)
+			require.NoError(t, err)
+			err = validateTemplate(templ)
+			if len(tc.unknown) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalidTemplate), "Expected an invalid template, got %v", err)
+			assert.ErrorContains(t, err, tc.unknown)
+		})
+	}
+}
+
+func TestValidateTemplate_Default(t *testing.T) {
+	assert.NoError(t, validateTemplate(defaultTempl))
+}
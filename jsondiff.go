@@ -0,0 +1,266 @@
+package linediff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidJSON = errors.New("invalid JSON")
+
+// JSON Patch operations, as described in RFC 6902.
+const (
+	JSONAdd     = "add"
+	JSONRemove  = "remove"
+	JSONReplace = "replace"
+)
+
+// JSONChange is a single change between two JSON documents.
+type JSONChange struct {
+	// Op is one of JSONAdd, JSONRemove, or JSONReplace.
+	Op string
+	// Path locates the changed value in JSONPath notation, like $.items[3].name.
+	// Array indexes are those of the removed value in the first document, or the added or replaced value in the second.
+	Path string
+	// Pointer locates the changed value as an RFC 6901 JSON Pointer, like /items/3/name, as it's used in the JSON Patch.
+	// Array indexes account for the changes before it, so they may differ from the indexes in Path.
+	Pointer string
+	// Old and New are the removed and added values, which are nil for additions and removals respectively.
+	Old, New any
+	// Diff is the token diff of a replaced string value, and is nil for other changes.
+	Diff *DiffSet
+}
+
+// JSONDiff is the structural difference between two JSON documents.
+// Objects are compared by key, and arrays are compared by aligning their elements, so key order and formatting are ignored.
+type JSONDiff struct {
+	Changes []JSONChange
+}
+
+// Changed returns true if the documents are different.
+func (d *JSONDiff) Changed() bool {
+	return len(d.Changes) > 0
+}
+
+// jsonPatchOperation is an operation of a JSON Patch.
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// Patch returns the changes as an RFC 6902 JSON Patch, which transforms the first document into the second when applied.
+func (d *JSONDiff) Patch() ([]byte, error) {
+	ops := make([]any, len(d.Changes))
+	for i, c := range d.Changes {
+		if c.Op == JSONRemove {
+			ops[i] = struct {
+				Op   string `json:"op"`
+				Path string `json:"path"`
+			}{c.Op, c.Pointer}
+			continue
+		}
+		ops[i] = jsonPatchOperation{Op: c.Op, Path: c.Pointer, Value: c.New}
+	}
+	return json.Marshal(ops)
+}
+
+// String renders each change on its own line, marked with + for additions, - for removals, and ~ for replacements.
+// Replaced strings are shown as a word diff of their tokens.
+func (d *JSONDiff) String() string {
+	var buf strings.Builder
+	for _, c := range d.Changes {
+		switch {
+		case c.Op == JSONAdd:
+			fmt.Fprintf(&buf, "+ %s: %s\n", c.Path, compactJSON(c.New))
+		case c.Op == JSONRemove:
+			fmt.Fprintf(&buf, "- %s: %s\n", c.Path, compactJSON(c.Old))
+		case c.Diff != nil:
+			fmt.Fprintf(&buf, "~ %s: %s\n", c.Path, c.Diff.WordDiff())
+		default:
+			fmt.Fprintf(&buf, "~ %s: %s -> %s\n", c.Path, compactJSON(c.Old), compactJSON(c.New))
+		}
+	}
+	return buf.String()
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// DiffJSON compares two JSON documents structurally, diffing replaced string values with SplitSpaces.
+func DiffJSON(a, b []byte) (*JSONDiff, error) {
+	return NewDiffer(SplitSpaces).DiffJSON(a, b)
+}
+
+// DiffJSON parses both documents and compares them structurally, using the Differ to diff replaced string values.
+func (d *Differ) DiffJSON(a, b []byte) (*JSONDiff, error) {
+	av, err := parseJSON(a)
+	if err != nil {
+		return nil, fmt.Errorf("%w: first document: %v", ErrInvalidJSON, err)
+	}
+	bv, err := parseJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: second document: %v", ErrInvalidJSON, err)
+	}
+	result := new(JSONDiff)
+	d.diffJSONValue(result, "$", "", av, bv)
+	return result, nil
+}
+
+// parseJSON decodes a single JSON document, keeping numbers as written.
+func parseJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the document")
+	}
+	return v, nil
+}
+
+func (d *Differ) diffJSONValue(result *JSONDiff, path, pointer string, a, b any) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			d.diffJSONObject(result, path, pointer, av, bv)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			d.diffJSONArray(result, path, pointer, av, bv)
+			return
+		}
+	}
+	if jsonEqual(a, b) {
+		return
+	}
+	change := JSONChange{Op: JSONReplace, Path: path, Pointer: pointer, Old: a, New: b}
+	as, aString := a.(string)
+	bs, bString := b.(string)
+	if aString && bString {
+		change.Diff = d.Diff(as, bs)
+	}
+	result.Changes = append(result.Changes, change)
+}
+
+func (d *Differ) diffJSONObject(result *JSONDiff, path, pointer string, a, b map[string]any) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var (
+			av, inA    = a[k]
+			bv, inB    = b[k]
+			keyPath    = path + jsonPathKey(k)
+			keyPointer = pointer + "/" + jsonPointerEscaper.Replace(k)
+		)
+		switch {
+		case !inB:
+			result.Changes = append(result.Changes, JSONChange{Op: JSONRemove, Path: keyPath, Pointer: keyPointer, Old: av})
+		case !inA:
+			result.Changes = append(result.Changes, JSONChange{Op: JSONAdd, Path: keyPath, Pointer: keyPointer, New: bv})
+		default:
+			d.diffJSONValue(result, keyPath, keyPointer, av, bv)
+		}
+	}
+}
+
+// diffJSONArray aligns array elements, then pairs changed elements in order so their differences are compared too.
+// Patch indexes are tracked as the changes are applied, so they're valid when the patch is applied in order.
+func (d *Differ) diffJSONArray(result *JSONDiff, path, pointer string, a, b []any) {
+	pairs := PairChanges(Align(len(a), len(b), func(i, j int) bool {
+		return jsonEqual(a[i], b[j])
+	}))
+	index := 0
+	for _, p := range pairs {
+		indexPointer := pointer + "/" + strconv.Itoa(index)
+		switch {
+		case p.B < 0:
+			result.Changes = append(result.Changes, JSONChange{Op: JSONRemove, Path: fmt.Sprintf("%s[%d]", path, p.A), Pointer: indexPointer, Old: a[p.A]})
+			continue
+		case p.A < 0:
+			result.Changes = append(result.Changes, JSONChange{Op: JSONAdd, Path: fmt.Sprintf("%s[%d]", path, p.B), Pointer: indexPointer, New: b[p.B]})
+		default:
+			d.diffJSONValue(result, fmt.Sprintf("%s[%d]", path, p.B), indexPointer, a[p.A], b[p.B])
+		}
+		index++
+	}
+}
+
+// jsonEqual compares decoded JSON values, treating numbers as equal if they have the same value, like 1.0 and 1.
+func jsonEqual(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if av == bv {
+			return true
+		}
+		// Numbers are compared exactly, so 1.0 equals 1, but large integers that round to the same float64 don't.
+		ar, aOK := new(big.Rat).SetString(av.String())
+		br, bOK := new(big.Rat).SetString(bv.String())
+		return aOK && bOK && ar.Cmp(br) == 0
+	default:
+		return a == b
+	}
+}
+
+var (
+	jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	jsonPathEscaper    = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+// jsonPathKey returns the JSONPath member accessor for the key, in dot notation if possible.
+func jsonPathKey(key string) string {
+	if jsonPathIdentifier.MatchString(key) {
+		return "." + key
+	}
+	return "['" + jsonPathEscaper.Replace(key) + "']"
+}
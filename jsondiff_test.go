@@ -0,0 +1,110 @@
+package linediff
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := map[string]struct {
+		a, b    string
+		changes []JSONChange
+	}{
+		"Key order and formatting": {
+			a: `{"a": 1, "b": [true, null]}`,
+			b: `{"b":[true,null],"a":1.0}`,
+		},
+		"Equal numbers written differently": {
+			a: `[1, 1.5, 100, 9007199254740993]`,
+			b: `[1.0, 15e-1, 1E2, 9007199254740993.00]`,
+		},
+		"Large integers": {
+			a: `{"id": 9007199254740993}`,
+			b: `{"id": 9007199254740992}`,
+			changes: []JSONChange{
+				{Op: JSONReplace, Path: "$.id", Pointer: "/id", Old: json.Number("9007199254740993"), New: json.Number("9007199254740992")},
+			},
+		},
+		"Object keys": {
+			a: `{"keep": 1, "old": "x", "num": 2}`,
+			b: `{"keep": 1, "new": [1], "num": 3}`,
+			changes: []JSONChange{
+				{Op: JSONAdd, Path: "$.new", Pointer: "/new", New: []any{json.Number("1")}},
+				{Op: JSONReplace, Path: "$.num", Pointer: "/num", Old: json.Number("2"), New: json.Number("3")},
+				{Op: JSONRemove, Path: "$.old", Pointer: "/old", Old: "x"},
+			},
+		},
+		"Escaped keys": {
+			a: `{"a/b": {"it's": 1}}`,
+			b: `{"a/b": {"it's": 2}}`,
+			changes: []JSONChange{
+				{Op: JSONReplace, Path: `$['a/b']['it\'s']`, Pointer: "/a~1b/it's", Old: json.Number("1"), New: json.Number("2")},
+			},
+		},
+		"Array alignment": {
+			a: `[1, 2, 3, 4]`,
+			b: `[0, 1, 3, 4, 5]`,
+			changes: []JSONChange{
+				{Op: JSONAdd, Path: "$[0]", Pointer: "/0", New: json.Number("0")},
+				{Op: JSONRemove, Path: "$[1]", Pointer: "/2", Old: json.Number("2")},
+				{Op: JSONAdd, Path: "$[4]", Pointer: "/4", New: json.Number("5")},
+			},
+		},
+		"Type change": {
+			a: `{"v": "1"}`,
+			b: `{"v": 1}`,
+			changes: []JSONChange{
+				{Op: JSONReplace, Path: "$.v", Pointer: "/v", Old: "1", New: json.Number("1")},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff, err := DiffJSON([]byte(tc.a), []byte(tc.b))
+			require.NoError(t, err)
+			assert.Equal(t, tc.changes, diff.Changes)
+			assert.Equal(t, len(tc.changes) > 0, diff.Changed())
+		})
+	}
+}
+
+func TestDiffJSON_NestedStrings(t *testing.T) {
+	diff, err := DiffJSON(
+		[]byte(`{"items": [{"id": 1}, {"id": 2, "name": "old blue widget"}]}`),
+		[]byte(`{"items": [{"id": 1}, {"id": 2, "name": "new blue widget"}]}`),
+	)
+	require.NoError(t, err)
+	require.Len(t, diff.Changes, 1)
+	change := diff.Changes[0]
+	assert.Equal(t, "$.items[1].name", change.Path)
+	assert.Equal(t, "[-old-]{+new+} blue widget", change.Diff.WordDiff())
+	assert.Equal(t, "~ $.items[1].name: [-old-]{+new+} blue widget\n", diff.String())
+
+	patch, err := diff.Patch()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op": "replace", "path": "/items/1/name", "value": "new blue widget"}]`, string(patch))
+}
+
+func TestJSONDiff_String(t *testing.T) {
+	diff, err := DiffJSON([]byte(`{"a": [1, 2], "b": {"c": null}}`), []byte(`{"a": [1], "b": {"c": false}, "d": {"e": "f"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "- $.a[1]: 2\n~ $.b.c: null -> false\n+ $.d: {\"e\":\"f\"}\n", diff.String())
+
+	patch, err := diff.Patch()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "remove", "path": "/a/1"},
+		{"op": "replace", "path": "/b/c", "value": false},
+		{"op": "add", "path": "/d", "value": {"e": "f"}}
+	]`, string(patch))
+}
+
+func TestDiffJSON_Invalid(t *testing.T) {
+	_, err := DiffJSON([]byte(`{`), []byte(`{}`))
+	assert.ErrorIs(t, err, ErrInvalidJSON)
+	_, err = DiffJSON([]byte(`{}`), []byte(`{} {}`))
+	assert.ErrorIs(t, err, ErrInvalidJSON)
+}
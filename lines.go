@@ -0,0 +1,43 @@
+package linediff
+
+import (
+	"strings"
+)
+
+// lineEndings are the line terminators recognized by SplitLines, with CRLF first so it's trimmed as a whole.
+var lineEndings = []string{"\r\n", "\n", "\r", "\u0085", "\u2028", "\u2029"}
+
+// lineEndingSet holds the runes that start a line terminator.
+var lineEndingSet = NewRuneSet("\n\r\u0085\u2028\u2029")
+
+// SplitLines splits input into lines, keeping the original terminator at the end of each line so the input can be rebuilt exactly.
+// Lines may end with LF, CRLF, a lone CR, NEL (U+0085), or the Unicode line and paragraph separators (U+2028 and U+2029).
+// The last line has no terminator if the input doesn't end with one.
+var SplitLines = NextFunc(func(tr *TokenReader) (string, bool) {
+	line, _ := tr.UntilFunc(lineEndingSet.Contains)
+	if ending, found := tr.AcceptToken("\r\n"); found {
+		return line + ending, true
+	}
+	// Otherwise the line ends with a single rune terminator, or at the end of the input.
+	if ending := tr.Peek(1); len(ending) > 0 {
+		tr.AcceptToken(ending)
+		return line + ending, true
+	}
+	return line, len(line) > 0
+})
+
+// TrimLineEnding removes a single line terminator recognized by SplitLines from the end of s.
+func TrimLineEnding(s string) string {
+	for _, ending := range lineEndings {
+		if strings.HasSuffix(s, ending) {
+			return strings.TrimSuffix(s, ending)
+		}
+	}
+	return s
+}
+
+// EqualIgnoringLineEndings compares tokens without their line terminators, for use as Differ.Equal.
+// This way files with LF line endings and files checked out with CRLF line endings are only different where their text is.
+func EqualIgnoringLineEndings(a, b string) bool {
+	return TrimLineEnding(a) == TrimLineEnding(b)
+}
@@ -0,0 +1,81 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens []string
+	}{
+		"No tokens": {
+			input:  "",
+			tokens: nil,
+		},
+		"No terminator": {
+			input:  "one line",
+			tokens: []string{"one line"},
+		},
+		"LF": {
+			input:  "a\nb\n",
+			tokens: []string{"a\n", "b\n"},
+		},
+		"CRLF": {
+			input:  "a\r\nb\r\nc",
+			tokens: []string{"a\r\n", "b\r\n", "c"},
+		},
+		"Lone CR": {
+			input:  "a\rb\r",
+			tokens: []string{"a\r", "b\r"},
+		},
+		"Empty lines": {
+			input:  "\n\r\n\r\r\n",
+			tokens: []string{"\n", "\r\n", "\r", "\r\n"},
+		},
+		"Unicode separators": {
+			input:  "a\u0085b\u2028c\u2029d",
+			tokens: []string{"a\u0085", "b\u2028", "c\u2029", "d"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := SplitLines.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+		})
+	}
+}
+
+func TestSplitLines_LongInput(t *testing.T) {
+	input := strings.Repeat("line\r\n", 100)
+	tokens := SplitLines.Split(NewStringTokenReaderWithSize(input, 16))
+	assert.Len(t, tokens, 100)
+	assert.Equal(t, input, strings.Join(tokens, ""))
+}
+
+func TestTrimLineEnding(t *testing.T) {
+	assert.Equal(t, "a", TrimLineEnding("a\r\n"))
+	assert.Equal(t, "a\r", TrimLineEnding("a\r\r\n"))
+	assert.Equal(t, "a", TrimLineEnding("a\u2028"))
+	assert.Equal(t, "a", TrimLineEnding("a"))
+}
+
+func TestDiffer_EqualIgnoringLineEndings(t *testing.T) {
+	var (
+		a = "one\ntwo\nthree\n"
+		b = "one\r\ntwo\r\n3\r\n"
+	)
+	d := NewDiffer(SplitLines)
+	assert.Equal(t, DiffStats{Added: 3, Removed: 3}, d.Diff(a, b).Stats())
+
+	d.Equal = EqualIgnoringLineEndings
+	ds := d.Diff(a, b)
+	assert.Equal(t, "one\ntwo\n[-three\n-]{+3\r\n+}", ds.WordDiff())
+
+	result := d.Merge(a, b, "zero\none\ntwo\nthree\n")
+	assert.Zero(t, result.Conflicts())
+	assert.Equal(t, "zero\none\ntwo\n3\r\n", result.String())
+}
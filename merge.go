@@ -0,0 +1,143 @@
+package linediff
+
+import (
+	"strings"
+)
+
+// MergeChunk is a region of a three-way merge.
+// A chunk without a conflict has the merged text, and a conflicting chunk has the text of each version instead.
+type MergeChunk struct {
+	Conflict bool
+	Text     string
+	Base     string
+	Ours     string
+	Theirs   string
+}
+
+// MergeResult is the result of a three-way merge, as a sequence of merged and conflicting chunks.
+type MergeResult struct {
+	Chunks []MergeChunk
+}
+
+// Conflicts returns the number of conflicting chunks.
+func (r *MergeResult) Conflicts() int {
+	var conflicts int
+	for _, chunk := range r.Chunks {
+		if chunk.Conflict {
+			conflicts++
+		}
+	}
+	return conflicts
+}
+
+// String renders the merge, with git style conflict markers around both versions of each conflict.
+// The markers after each version start on a new line, so a line break is only added if the version doesn't already end with one.
+func (r *MergeResult) String() string {
+	var buf strings.Builder
+	for _, chunk := range r.Chunks {
+		if !chunk.Conflict {
+			buf.WriteString(chunk.Text)
+			continue
+		}
+		buf.WriteString("<<<<<<< ours\n")
+		writeLine(&buf, chunk.Ours)
+		buf.WriteString("=======\n")
+		writeLine(&buf, chunk.Theirs)
+		buf.WriteString(">>>>>>> theirs\n")
+	}
+	return buf.String()
+}
+
+// writeLine writes text followed by a line break, unless it's empty or already ends with a line break.
+func writeLine(buf *strings.Builder, text string) {
+	buf.WriteString(text)
+	if len(text) > 0 && !strings.HasSuffix(text, "\n") {
+		buf.WriteString("\n")
+	}
+}
+
+// add appends a chunk, combining it with the previous chunk if neither conflicts.
+func (r *MergeResult) add(chunk MergeChunk) {
+	if last := len(r.Chunks) - 1; last >= 0 && !chunk.Conflict && !r.Chunks[last].Conflict {
+		r.Chunks[last].Text += chunk.Text
+		return
+	}
+	r.Chunks = append(r.Chunks, chunk)
+}
+
+// Merge combines the changes made to base in ours and theirs, using a minimal alignment of each version's tokens with base.
+// Tokens that are unchanged in both versions are kept, and a region changed in only one version, or changed the same way in both, takes that change.
+// A region changed differently in each version is a conflict.
+func (d *Differ) Merge(base, ours, theirs string) *MergeResult {
+	var (
+		bt = d.split(base)
+		ot = d.split(ours)
+		tt = d.split(theirs)
+		mo = d.matchIndexes(bt, ot)
+		mt = d.matchIndexes(bt, tt)
+		r  = new(MergeResult)
+
+		i, j, k int
+	)
+	for i < len(bt) || j < len(ot) || k < len(tt) {
+		if i < len(bt) && mo[i] == j && mt[i] == k {
+			r.add(MergeChunk{Text: bt[i]})
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// The changed region ends at the next base token that's in both versions.
+		end, oursEnd, theirsEnd := i, len(ot), len(tt)
+		for end < len(bt) && (mo[end] < 0 || mt[end] < 0) {
+			end++
+		}
+		if end < len(bt) {
+			oursEnd, theirsEnd = mo[end], mt[end]
+		}
+		var (
+			b = strings.Join(bt[i:end], "")
+			o = strings.Join(ot[j:oursEnd], "")
+			t = strings.Join(tt[k:theirsEnd], "")
+		)
+		switch {
+		case d.equalTokens(ot[j:oursEnd], bt[i:end]):
+			r.add(MergeChunk{Text: t})
+		case d.equalTokens(tt[k:theirsEnd], bt[i:end]), d.equalTokens(ot[j:oursEnd], tt[k:theirsEnd]):
+			r.add(MergeChunk{Text: o})
+		default:
+			r.add(MergeChunk{Conflict: true, Base: b, Ours: o, Theirs: t})
+		}
+		i, j, k = end, oursEnd, theirsEnd
+	}
+	return r
+}
+
+// matchIndexes returns the index in b of each element of a in a minimal alignment, or -1 if it isn't matched.
+func (d *Differ) matchIndexes(a, b []string) []int {
+	indexes := make([]int, len(a))
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	pairs := Align(len(a), len(b), func(i, j int) bool {
+		return d.equal(a[i], b[j])
+	})
+	for _, p := range pairs {
+		if p.Matched() {
+			indexes[p.A] = p.B
+		}
+	}
+	return indexes
+}
+
+// equalTokens returns true if a and b have the same number of tokens, and each is equal.
+func (d *Differ) equalTokens(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !d.equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffer_Merge(t *testing.T) {
+	base := "the quick brown fox jumps"
+
+	tests := map[string]struct {
+		ours, theirs string
+		expected     string
+		conflicts    int
+	}{
+		"No changes": {
+			ours:     base,
+			theirs:   base,
+			expected: base,
+		},
+		"Changes in different places": {
+			ours:     "the slow brown fox jumps",
+			theirs:   "the quick brown fox leaps",
+			expected: "the slow brown fox leaps",
+		},
+		"Same change in both": {
+			ours:     "the quick red fox jumps",
+			theirs:   "the quick red fox jumps",
+			expected: "the quick red fox jumps",
+		},
+		"Conflicting changes": {
+			ours:      "the quick red fox jumps",
+			theirs:    "the quick grey fox jumps",
+			expected:  "the quick <<<<<<< ours\nred\n=======\ngrey\n>>>>>>> theirs\n fox jumps",
+			conflicts: 1,
+		},
+		"Additions at the end": {
+			ours:     "the quick brown fox jumps high",
+			theirs:   base,
+			expected: "the quick brown fox jumps high",
+		},
+	}
+
+	d := NewDiffer(SplitSpaces)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := d.Merge(base, tc.ours, tc.theirs)
+			assert.Equal(t, tc.expected, result.String())
+			assert.Equal(t, tc.conflicts, result.Conflicts())
+		})
+	}
+}
+
+func TestDiffer_Merge_Lines(t *testing.T) {
+	tests := map[string]struct {
+		base, ours, theirs string
+		expected           string
+	}{
+		"Conflicting lines": {
+			base:     "a\nb\nc\n",
+			ours:     "a\nours\nc\n",
+			theirs:   "a\ntheirs\nc\n",
+			expected: "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc\n",
+		},
+		"Removed and changed": {
+			base:     "a\nb\nc\n",
+			ours:     "a\nc\n",
+			theirs:   "a\ntheirs\nc\n",
+			expected: "a\n<<<<<<< ours\n=======\ntheirs\n>>>>>>> theirs\nc\n",
+		},
+		"Last line without line break": {
+			base:     "a\nb",
+			ours:     "a\nours",
+			theirs:   "a\ntheirs",
+			expected: "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n",
+		},
+	}
+
+	d := NewDiffer(SplitLines)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := d.Merge(tc.base, tc.ours, tc.theirs)
+			assert.Equal(t, tc.expected, result.String())
+			assert.Equal(t, 1, result.Conflicts())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package linediff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrPatchMismatch = errors.New("patch doesn't match input")
+
+// Apply uses the DiffSet as a patch to reproduce B from A.
+// The same and removed segments must match a exactly and in order, and added segments are inserted in their place.
+// This requires that the tokens of the diff cover the whole input, which is true of splitters that keep delimiters as tokens.
+func (s *DiffSet) Apply(a string) (string, error) {
+	return s.patch(a, Removed, Added)
+}
+
+// Revert uses the DiffSet as a reverse patch to reproduce A from B.
+// The same and added segments must match b exactly and in order, and removed segments are inserted in their place.
+func (s *DiffSet) Revert(b string) (string, error) {
+	return s.patch(b, Added, Removed)
+}
+
+// patch consumes segments tagged Same or from in input, and writes segments tagged Same or to.
+// Same segments that aren't identical in A and B are consumed and written with their text on each side.
+func (s *DiffSet) patch(input string, from, to Tag) (string, error) {
+	var (
+		buf strings.Builder
+		pos int
+	)
+	for i, segment := range s.segments {
+		switch s.tags[i] {
+		case Same, from:
+			consumed, written := segment, segment
+			if s.tags[i] == Same && from == Added {
+				consumed = s.textB(i)
+			} else if s.tags[i] == Same {
+				written = s.textB(i)
+			}
+			if !strings.HasPrefix(input[pos:], consumed) {
+				return "", fmt.Errorf("%w: expected %q at offset %d", ErrPatchMismatch, consumed, pos)
+			}
+			pos += len(consumed)
+			if s.tags[i] == Same {
+				buf.WriteString(written)
+			}
+		case to:
+			buf.WriteString(segment)
+		}
+	}
+	if pos < len(input) {
+		return "", fmt.Errorf("%w: unexpected text at offset %d", ErrPatchMismatch, pos)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,68 @@
+package linediff
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDiffSet_Apply(t *testing.T) {
+	a, b := "a simple string", "a less simple thing"
+	ds := Diff(a, b)
+
+	patched, err := ds.Apply(a)
+	assert.NoError(t, err)
+	assert.Equal(t, b, patched)
+
+	reverted, err := ds.Revert(b)
+	assert.NoError(t, err)
+	assert.Equal(t, a, reverted)
+}
+
+func TestDiffSet_Apply_IgnoringLineEndings(t *testing.T) {
+	var (
+		a = "one\ntwo\nthree\n"
+		b = "one\r\ntwo\r\n3\r\n"
+		d = NewDiffer(SplitLines)
+	)
+	d.Equal = EqualIgnoringLineEndings
+	ds := d.Diff(a, b)
+	assert.Equal(t, "one\ntwo\n[-three\n-]{+3\r\n+}", ds.WordDiff())
+
+	// Patches are written and read as JSON by the patch command.
+	data, err := json.Marshal(ds)
+	require.NoError(t, err)
+	parsed := new(DiffSet)
+	require.NoError(t, json.Unmarshal(data, parsed))
+
+	for name, ds := range map[string]*DiffSet{"Diff": ds, "JSON": parsed} {
+		t.Run(name, func(t *testing.T) {
+			patched, err := ds.Apply(a)
+			assert.NoError(t, err)
+			assert.Equal(t, b, patched)
+
+			reverted, err := ds.Revert(b)
+			assert.NoError(t, err)
+			assert.Equal(t, a, reverted)
+		})
+	}
+}
+
+func TestDiffSet_Apply_Mismatch(t *testing.T) {
+	ds := Diff("a simple string", "a less simple string")
+
+	tests := map[string]string{
+		"Different text": "a complex string",
+		"Trailing text":  "a simple string too",
+		"Missing text":   "a simple",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ds.Apply(input)
+			assert.True(t, errors.Is(err, ErrPatchMismatch), "Expected a patch mismatch, got %v", err)
+		})
+	}
+}
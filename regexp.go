@@ -0,0 +1,62 @@
+package linediff
+
+import (
+	"regexp"
+)
+
+// RegexpMode determines which text a RegexpSplitter emits as tokens.
+type RegexpMode int
+
+const (
+	// RegexpMatch emits each match of the pattern as a token, dropping any text between matches.
+	RegexpMatch RegexpMode = iota
+	// RegexpSplit treats matches as separators, emitting both the text between matches and the separators as tokens.
+	RegexpSplit
+)
+
+// RegexpSplitter splits input with a regular expression.
+// Empty matches are never emitted as tokens, but split the input in RegexpSplit mode, so patterns like `\b` can be used.
+type RegexpSplitter struct {
+	Regexp *regexp.Regexp
+	Mode   RegexpMode
+}
+
+// SplitRegexp compiles the pattern, and returns a RegexpSplitter in RegexpMatch mode.
+func SplitRegexp(pattern string) (*RegexpSplitter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexpSplitter{Regexp: re}, nil
+}
+
+// MustSplitRegexp is like SplitRegexp, but panics if the pattern can't be compiled.
+func MustSplitRegexp(pattern string) *RegexpSplitter {
+	s, err := SplitRegexp(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *RegexpSplitter) Split(tr *TokenReader) []string {
+	var (
+		tokens []string
+		input  = readAll(tr)
+		last   int
+	)
+	for _, loc := range s.Regexp.FindAllStringIndex(input, -1) {
+		start, end := loc[0], loc[1]
+		if s.Mode == RegexpSplit && start > last {
+			tokens = append(tokens, input[last:start])
+		}
+		if end > start {
+			tokens = append(tokens, input[start:end])
+		}
+		last = end
+	}
+	if s.Mode == RegexpSplit && last < len(input) {
+		tokens = append(tokens, input[last:])
+	}
+	return tokens
+}
@@ -0,0 +1,71 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRegexpSplitter_Split(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		mode    RegexpMode
+		input   string
+		tokens  []string
+	}{
+		"No tokens": {
+			pattern: `\w+`,
+			input:   "",
+			tokens:  nil,
+		},
+		"Match": {
+			pattern: `[A-Za-z]+|\d+(\.\d+)?|\S`,
+			input:   "total = 3.14*r;",
+			tokens:  []string{"total", "=", "3.14", "*", "r", ";"},
+		},
+		"Split": {
+			pattern: `[,;]\s*`,
+			mode:    RegexpSplit,
+			input:   "a, b;c,",
+			tokens:  []string{"a", ", ", "b", ";", "c", ","},
+		},
+		"Split leading separator": {
+			pattern: `-+`,
+			mode:    RegexpSplit,
+			input:   "--a--b",
+			tokens:  []string{"--", "a", "--", "b"},
+		},
+		"Split empty matches": {
+			pattern: `\b`,
+			mode:    RegexpSplit,
+			input:   "hi, you",
+			tokens:  []string{"hi", ", ", "you"},
+		},
+		"Match ignores empty matches": {
+			pattern: `\d*`,
+			input:   "a12b3",
+			tokens:  []string{"12", "3"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := MustSplitRegexp(tc.pattern)
+			s.Mode = tc.mode
+			tokens := s.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+		})
+	}
+}
+
+func TestSplitRegexp_Invalid(t *testing.T) {
+	_, err := SplitRegexp(`(`)
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		MustSplitRegexp(`(`)
+	})
+}
+
+func TestDiffSplit_Regexp(t *testing.T) {
+	ds := DiffSplit("x=1.5", "x = 1.25", MustSplitRegexp(`[A-Za-z]+|\d+(\.\d+)?|\S`))
+	assert.Equal(t, "x=[-1.5-]{+1.25+}", ds.WordDiff())
+}
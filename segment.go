@@ -0,0 +1,173 @@
+package linediff
+
+import (
+	"bufio"
+	_ "embed"
+	"github.com/rivo/uniseg"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Segmenter splits a run of text in a script that doesn't separate words with spaces, like Thai, Chinese, or Japanese, into words.
+// The returned words must join back into the text.
+type Segmenter interface {
+	Segment(text string) []string
+}
+
+var (
+	//go:embed dictionaries/thai.txt
+	thaiWords string
+	//go:embed dictionaries/chinese.txt
+	chineseWords string
+	//go:embed dictionaries/japanese.txt
+	japaneseWords string
+)
+
+// DefaultDictionary holds common Thai, Chinese, and Japanese words, and is used by SplitWords.
+// It's small, so use With or ReadDictionary to add the vocabulary of a specific domain.
+var DefaultDictionary = mustReadDictionary(thaiWords, chineseWords, japaneseWords)
+
+// Dictionary is a Segmenter that splits text into known words, preferring the fewest unknown characters, then the fewest words.
+// Unknown characters are split individually, except that runs of unknown Thai or Katakana characters are kept together.
+// A Dictionary isn't changed once created, so it's safe to use concurrently.
+type Dictionary struct {
+	words map[string]bool
+	// maxLen is the length of the longest word in grapheme clusters.
+	maxLen int
+}
+
+// NewDictionary creates a Dictionary with each of the words.
+func NewDictionary(words ...string) *Dictionary {
+	d := &Dictionary{words: map[string]bool{}}
+	d.add(words...)
+	return d
+}
+
+// ReadDictionary creates a Dictionary from a word list with one word per line.
+// Blank lines and lines starting with # are skipped.
+func ReadDictionary(r io.Reader) (*Dictionary, error) {
+	var (
+		d       = NewDictionary()
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if len(word) == 0 || strings.HasPrefix(word, "#") {
+			continue
+		}
+		d.add(word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func mustReadDictionary(lists ...string) *Dictionary {
+	d, err := ReadDictionary(strings.NewReader(strings.Join(lists, "\n")))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// With returns a new Dictionary with the words in this one and the given words.
+func (d *Dictionary) With(words ...string) *Dictionary {
+	with := &Dictionary{words: make(map[string]bool, len(d.words)+len(words)), maxLen: d.maxLen}
+	for word := range d.words {
+		with.words[word] = true
+	}
+	with.add(words...)
+	return with
+}
+
+func (d *Dictionary) add(words ...string) {
+	for _, word := range words {
+		if len(word) == 0 {
+			continue
+		}
+		d.words[word] = true
+		d.maxLen = max(d.maxLen, uniseg.GraphemeClusterCount(word))
+	}
+}
+
+// Contains returns true if the word is in the Dictionary.
+func (d *Dictionary) Contains(word string) bool {
+	return d.words[word]
+}
+
+// Segment splits the text into the fewest words with the fewest unknown characters.
+func (d *Dictionary) Segment(text string) []string {
+	// bounds holds the byte offset of each grapheme cluster, and the end of the text.
+	bounds := []int{0}
+	state := -1
+	for rest := text; len(rest) > 0; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		bounds = append(bounds, bounds[len(bounds)-1]+len(cluster))
+	}
+	n := len(bounds) - 1
+
+	// best[i] is the best segmentation of the text from cluster i, found by working back from the end.
+	type step struct {
+		unknown, words, next int
+	}
+	best := make([]step, n+1)
+	for i := n - 1; i >= 0; i-- {
+		best[i] = step{unknown: best[i+1].unknown + 1, words: best[i+1].words + 1, next: i + 1}
+		// Longer words are tried first, so they're preferred when there's a tie.
+		for j := min(n, i+d.maxLen); j > i; j-- {
+			if !d.words[text[bounds[i]:bounds[j]]] {
+				continue
+			}
+			s := step{unknown: best[j].unknown, words: best[j].words + 1, next: j}
+			if s.unknown < best[i].unknown || (s.unknown == best[i].unknown && s.words < best[i].words) {
+				best[i] = s
+			}
+		}
+	}
+
+	var (
+		words       []string
+		lastUnknown bool
+	)
+	for i := 0; i < n; i = best[i].next {
+		word := text[bounds[i]:bounds[best[i].next]]
+		unknown := !d.words[word]
+		if last := len(words) - 1; unknown && lastUnknown && keepsUnknownRuns(words[last], word) {
+			words[last] += word
+			continue
+		}
+		words = append(words, word)
+		lastUnknown = unknown
+	}
+	return words
+}
+
+// keepsUnknownRuns returns true if both unknown clusters are Thai or Katakana, which are more likely parts of a longer word than words
+// themselves.
+func keepsUnknownRuns(a, b string) bool {
+	ra, rb := []rune(a)[0], []rune(b)[0]
+	for _, script := range []*unicode.RangeTable{unicode.Thai, unicode.Katakana} {
+		if unicode.Is(script, ra) && unicode.Is(script, rb) {
+			return true
+		}
+	}
+	return isProlongedSoundMark(rb) && unicode.Is(unicode.Katakana, ra)
+}
+
+// isUnspaced returns true if the word is only in scripts that don't separate words with spaces, and which Segmenters split.
+func isUnspaced(word string) bool {
+	for _, r := range word {
+		if !unicode.In(r, unicode.Thai, unicode.Han, unicode.Hiragana, unicode.Katakana) && !isProlongedSoundMark(r) {
+			return false
+		}
+	}
+	return len(word) > 0
+}
+
+// isProlongedSoundMark returns true for ー, which is used in Katakana words but isn't in the Katakana script.
+func isProlongedSoundMark(r rune) bool {
+	return r == 'ー'
+}
@@ -0,0 +1,215 @@
+package linediff
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitGo splits Go source into the tokens of the Go language with go/scanner, keeping whitespace runs as tokens between them.
+// String literals and comments are single tokens, so an edit inside one only changes that token.
+// Invalid source is still split, with any illegal characters as their own tokens.
+var SplitGo = SplitterFunc(func(tr *TokenReader) []string {
+	var (
+		src    = readAll(tr)
+		starts []int
+		s      scanner.Scanner
+	)
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// Automatically inserted semicolons aren't in the source.
+			continue
+		}
+		starts = append(starts, file.Offset(pos))
+	}
+	return splitAtStarts(src, starts)
+})
+
+// splitAtStarts splits src at the start of each token, separating the whitespace that follows each token into its own token.
+func splitAtStarts(src string, starts []int) []string {
+	var tokens []string
+	appendWithSpace := func(text string) {
+		trimmed := strings.TrimRightFunc(text, unicode.IsSpace)
+		if len(trimmed) > 0 {
+			tokens = append(tokens, trimmed)
+		}
+		if len(trimmed) < len(text) {
+			tokens = append(tokens, text[len(trimmed):])
+		}
+	}
+	last := 0
+	for _, start := range starts {
+		if start > last {
+			appendWithSpace(src[last:start])
+		}
+		last = start
+	}
+	if last < len(src) {
+		appendWithSpace(src[last:])
+	}
+	return tokens
+}
+
+// Lexer splits source code in a C-like language into identifiers, numbers, string literals, comments, operators, and whitespace runs.
+// String literals and comments are single tokens, so an edit inside one only changes that token.
+// Any other rune is a token by itself.
+type Lexer struct {
+	// LineComments are the prefixes of comments that continue to the end of the line, like "//".
+	LineComments []string
+	// BlockComments are the start and end delimiters of comments that may span lines, like "/*" and "*/".
+	BlockComments [][2]string
+	// Quotes are the runes that start and end string literals.
+	Quotes string
+	// Escape is the rune that escapes the following rune within a string literal, like a backslash, or 0 if there are no escapes.
+	Escape rune
+	// DoubledQuotes allows a quote to be escaped by repeating it, like 'it''s' in SQL.
+	DoubledQuotes bool
+	// Operators are the operators with more than one rune, which are matched longest first.
+	Operators []string
+}
+
+// SplitCLike splits source in languages with C-like syntax, including C, C++, Java, JavaScript, and C#.
+var SplitCLike = &Lexer{
+	LineComments:  []string{"//"},
+	BlockComments: [][2]string{{"/*", "*/"}},
+	Quotes:        "\"'`",
+	Escape:        '\\',
+	Operators: []string{
+		">>>=", "<<=", ">>=", "===", "!==", "...", ">>>", "**=",
+		"&&", "||", "==", "!=", "<=", ">=", "++", "--", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+		"<<", ">>", "->", "::", "=>", "**", "??", "?.",
+	},
+}
+
+// SplitSQL splits SQL statements, with SQL comments and quoting.
+var SplitSQL = &Lexer{
+	LineComments:  []string{"--"},
+	BlockComments: [][2]string{{"/*", "*/"}},
+	Quotes:        "'\"`",
+	DoubledQuotes: true,
+	Operators:     []string{"<>", "!=", "<=", ">=", "||", "::"},
+}
+
+func (l *Lexer) Split(tr *TokenReader) []string {
+	var (
+		tokens []string
+		src    = readAll(tr)
+	)
+	for len(src) > 0 {
+		n := l.tokenLen(src)
+		tokens = append(tokens, src[:n])
+		src = src[n:]
+	}
+	return tokens
+}
+
+// tokenLen returns the length in bytes of the token at the start of src, which must not be empty.
+func (l *Lexer) tokenLen(src string) int {
+	r, size := utf8.DecodeRuneInString(src)
+	switch {
+	case unicode.IsSpace(r):
+		return prefixLen(src, unicode.IsSpace)
+	case isIdentStart(r):
+		return prefixLen(src, isIdentPart)
+	case unicode.IsDigit(r), r == '.' && len(src) > 1 && unicode.IsDigit(rune(src[1])):
+		return numberLen(src)
+	case strings.ContainsRune(l.Quotes, r):
+		return l.quotedLen(src, r, size)
+	}
+	for _, prefix := range l.LineComments {
+		if strings.HasPrefix(src, prefix) {
+			if i := strings.IndexAny(src, "\r\n"); i >= 0 {
+				return i
+			}
+			return len(src)
+		}
+	}
+	for _, delims := range l.BlockComments {
+		if strings.HasPrefix(src, delims[0]) {
+			if i := strings.Index(src[len(delims[0]):], delims[1]); i >= 0 {
+				return len(delims[0]) + i + len(delims[1])
+			}
+			return len(src)
+		}
+	}
+	longest := 0
+	for _, op := range l.Operators {
+		if len(op) > longest && strings.HasPrefix(src, op) {
+			longest = len(op)
+		}
+	}
+	if longest > 0 {
+		return longest
+	}
+	return size
+}
+
+// quotedLen returns the length of a string literal starting with quote, including both quotes.
+// An unterminated literal continues to the end of the input.
+func (l *Lexer) quotedLen(src string, quote rune, size int) int {
+	for i := size; i < len(src); {
+		r, n := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case l.Escape != 0 && r == l.Escape:
+			_, escaped := utf8.DecodeRuneInString(src[i+n:])
+			i += n + escaped
+			continue
+		case r == quote:
+			if l.DoubledQuotes && strings.HasPrefix(src[i+n:], string(quote)) {
+				i += 2 * n
+				continue
+			}
+			return i + n
+		}
+		i += n
+	}
+	return len(src)
+}
+
+// numberLen returns the length of a number literal, including any radix prefix, fraction, exponent, or suffix.
+func numberLen(src string) int {
+	exponents := "eE"
+	if len(src) > 1 && src[0] == '0' && (src[1] == 'x' || src[1] == 'X') {
+		exponents = "pP"
+	}
+	i := 0
+	for i < len(src) {
+		r, n := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case r == '+' || r == '-':
+			// Signs are only part of a number directly after an exponent.
+			if !strings.ContainsRune(exponents, rune(src[i-1])) {
+				return i
+			}
+		case r == '.', r == '_', unicode.IsLetter(r), unicode.IsDigit(r):
+		default:
+			return i
+		}
+		i += n
+	}
+	return i
+}
+
+// prefixLen returns the length of the prefix of src with runes matching f.
+func prefixLen(src string, f func(r rune) bool) int {
+	if i := strings.IndexFunc(src, func(r rune) bool { return !f(r) }); i >= 0 {
+		return i
+	}
+	return len(src)
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
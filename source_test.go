@@ -0,0 +1,97 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitGo(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens []string
+	}{
+		"No tokens": {
+			input:  "",
+			tokens: nil,
+		},
+		"Statement": {
+			input:  "x := a[i]+1.5e-3",
+			tokens: []string{"x", " ", ":=", " ", "a", "[", "i", "]", "+", "1.5e-3"},
+		},
+		"Strings and comments": {
+			input:  "f(\"a b\", `c\nd`) // call f\n\t/* done */",
+			tokens: []string{"f", "(", `"a b"`, ",", " ", "`c\nd`", ")", " ", "// call f", "\n\t", "/* done */"},
+		},
+		"Leading whitespace and newlines": {
+			input:  "\n\treturn x\n}\n",
+			tokens: []string{"\n\t", "return", " ", "x", "\n", "}", "\n"},
+		},
+		"Illegal characters": {
+			input:  "a # b",
+			tokens: []string{"a", " ", "#", " ", "b"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := SplitGo.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+			assert.Equal(t, tc.input, strings.Join(tokens, ""))
+		})
+	}
+}
+
+func TestLexer_Split(t *testing.T) {
+	tests := map[string]struct {
+		lexer  *Lexer
+		input  string
+		tokens []string
+	}{
+		"No tokens": {
+			lexer:  SplitCLike,
+			input:  "",
+			tokens: nil,
+		},
+		"C-like": {
+			lexer:  SplitCLike,
+			input:  "if (x >>= 0x1Fp+2) { y = .5e-1; }",
+			tokens: []string{"if", " ", "(", "x", " ", ">>=", " ", "0x1Fp+2", ")", " ", "{", " ", "y", " ", "=", " ", ".5e-1", ";", " ", "}"},
+		},
+		"C-like strings and comments": {
+			lexer:  SplitCLike,
+			input:  "s = \"say \\\"hi\\\"\"; // greet\n/* a\nb */'c'",
+			tokens: []string{"s", " ", "=", " ", `"say \"hi\""`, ";", " ", "// greet", "\n", "/* a\nb */", "'c'"},
+		},
+		"Unterminated": {
+			lexer:  SplitCLike,
+			input:  "x /* open",
+			tokens: []string{"x", " ", "/* open"},
+		},
+		"SQL": {
+			lexer:  SplitSQL,
+			input:  "SELECT name FROM t WHERE note <> 'it''s' -- check\nAND n>=1;",
+			tokens: []string{"SELECT", " ", "name", " ", "FROM", " ", "t", " ", "WHERE", " ", "note", " ", "<>", " ", "'it''s'", " ", "-- check", "\n", "AND", " ", "n", ">=", "1", ";"},
+		},
+		"SQL backslash isn't an escape": {
+			lexer:  SplitSQL,
+			input:  `path = 'C:\' AND x = 1`,
+			tokens: []string{"path", " ", "=", " ", `'C:\'`, " ", "AND", " ", "x", " ", "=", " ", "1"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := tc.lexer.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+		})
+	}
+}
+
+func TestDiffSplit_Source(t *testing.T) {
+	ds := DiffSplit(`fmt.Println("hello world")`, `fmt.Println("hello, world")`, SplitGo)
+	assert.Equal(t, `fmt.Println([-"hello world"-]{+"hello, world"+})`, ds.WordDiff())
+
+	ds = DiffSplit("SELECT a FROM t WHERE b = 'old note' -- by note", "SELECT a FROM t WHERE b = 'new note' -- by note", SplitSQL)
+	assert.Equal(t, "SELECT a FROM t WHERE b = [-'old note'-]{+'new note'+} -- by note", ds.WordDiff())
+}
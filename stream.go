@@ -0,0 +1,138 @@
+package linediff
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Token is a single token read from a TokenStream.
+type Token struct {
+	Text string
+	// Offset is the position of the token in the input, in runes.
+	Offset int
+}
+
+// TokenStream yields the tokens of an input one at a time.
+type TokenStream interface {
+	// Next returns the next token, or io.EOF after the last one.
+	// If there's an error from reading the input, it's returned instead of io.EOF, and the last token may have been cut short by it.
+	Next() (Token, error)
+}
+
+// StreamSplitter is like Splitter, but yields tokens one at a time and reports errors from reading the input instead of ending early.
+type StreamSplitter interface {
+	Stream(tr *TokenReader) TokenStream
+}
+
+// NextFunc reads the next token from the TokenReader, returning false when there are no more tokens.
+// It implements both Splitter and StreamSplitter, so a splitter written as a NextFunc streams its tokens as they're read.
+type NextFunc func(tr *TokenReader) (string, bool)
+
+// Split reads every token. Errors from reading the input end the tokens early, and are available from tr.Err.
+func (f NextFunc) Split(tr *TokenReader) []string {
+	var tokens []string
+	for {
+		token, found := f(tr)
+		if !found {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func (f NextFunc) Stream(tr *TokenReader) TokenStream {
+	return &nextStream{next: f, tr: tr}
+}
+
+type nextStream struct {
+	next   NextFunc
+	tr     *TokenReader
+	offset int
+	err    error
+}
+
+func (s *nextStream) Next() (Token, error) {
+	if s.err != nil {
+		return Token{}, s.err
+	}
+	text, found := s.next(s.tr)
+	if !found {
+		s.err = io.EOF
+		if err := s.tr.Err(); err != nil {
+			s.err = err
+		}
+		return Token{}, s.err
+	}
+	token := Token{Text: text, Offset: s.offset}
+	s.offset += utf8.RuneCountInString(text)
+	return token, nil
+}
+
+// Stream splits the whole input with the SplitterFunc, then yields its tokens.
+// If there's an error from reading the input, it's returned after the tokens instead of io.EOF.
+func (f SplitterFunc) Stream(tr *TokenReader) TokenStream {
+	return &sliceStream{tokens: f(tr), err: tr.Err()}
+}
+
+type sliceStream struct {
+	tokens []string
+	err    error
+	offset int
+}
+
+func (s *sliceStream) Next() (Token, error) {
+	if len(s.tokens) == 0 {
+		if s.err != nil {
+			return Token{}, s.err
+		}
+		return Token{}, io.EOF
+	}
+	token := Token{Text: s.tokens[0], Offset: s.offset}
+	s.tokens = s.tokens[1:]
+	s.offset += utf8.RuneCountInString(token.Text)
+	return token, nil
+}
+
+// ReadTokens reads every token from the TokenReader with the Splitter, streaming them if it's a StreamSplitter.
+// Unlike Split, an error from reading the input is returned, rather than the tokens read before it.
+func ReadTokens(split Splitter, tr *TokenReader) ([]string, error) {
+	stream, ok := split.(StreamSplitter)
+	if !ok {
+		tokens := split.Split(tr)
+		if err := tr.Err(); err != nil {
+			return nil, err
+		}
+		return tokens, nil
+	}
+	var (
+		tokens []string
+		ts     = stream.Stream(tr)
+	)
+	for {
+		token, err := ts.Next()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token.Text)
+	}
+}
+
+// DiffReaders reads and diffs two inputs, such as files or network streams.
+// An error from reading either input is returned instead of a diff of the partial input.
+func (d *Differ) DiffReaders(a, b io.Reader) (*DiffSet, error) {
+	if d.Splitter == nil {
+		panic("nil splitter")
+	}
+	as, err := ReadTokens(d.Splitter, NewTokenReaderWithSize(a, d.BufferSize))
+	if err != nil {
+		return nil, err
+	}
+	bs, err := ReadTokens(d.Splitter, NewTokenReaderWithSize(b, d.BufferSize))
+	if err != nil {
+		return nil, err
+	}
+	return d.DiffTokens(as, bs), nil
+}
@@ -0,0 +1,108 @@
+package linediff
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var errBroken = errors.New("broken connection")
+
+// brokenReader returns the text, then fails.
+func brokenReader(text string) io.Reader {
+	return io.MultiReader(strings.NewReader(text), iotest.ErrReader(errBroken))
+}
+
+func TestNextFunc_Stream(t *testing.T) {
+	ts := StreamSpaces.Stream(NewStringTokenReader(" hello  world"))
+	var tokens []Token
+	for {
+		token, err := ts.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, []Token{
+		{Text: " ", Offset: 0},
+		{Text: "hello", Offset: 1},
+		{Text: " ", Offset: 6},
+		{Text: " ", Offset: 7},
+		{Text: "world", Offset: 8},
+	}, tokens)
+
+	_, err := ts.Next()
+	assert.Equal(t, io.EOF, err, "EOF should be returned by every later call")
+}
+
+func TestNextFunc_StreamError(t *testing.T) {
+	ts := StreamSpaces.Stream(NewTokenReader(brokenReader("a b")))
+	var tokens []string
+	for {
+		token, err := ts.Next()
+		if err != nil {
+			assert.ErrorIs(t, err, errBroken)
+			break
+		}
+		tokens = append(tokens, token.Text)
+	}
+	assert.Equal(t, []string{"a", " ", "b"}, tokens)
+
+	_, err := ts.Next()
+	assert.ErrorIs(t, err, errBroken, "The error should be returned by every later call")
+}
+
+func TestSplitterFunc_Stream(t *testing.T) {
+	ts := SplitGraphemes.Stream(NewTokenReader(brokenReader("aé")))
+	token, err := ts.Next()
+	require.NoError(t, err)
+	assert.Equal(t, Token{Text: "a"}, token)
+	token, err = ts.Next()
+	require.NoError(t, err)
+	assert.Equal(t, Token{Text: "é", Offset: 1}, token)
+	_, err = ts.Next()
+	assert.ErrorIs(t, err, errBroken)
+}
+
+func TestReadTokens(t *testing.T) {
+	tokens, err := ReadTokens(SplitSpaces, NewStringTokenReader("a b"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", " ", "b"}, tokens)
+
+	for name, split := range map[string]Splitter{
+		"NextFunc":       StreamSpaces,
+		"SplitterFunc":   SplitWords,
+		"Other splitter": MustSplitRegexp(`\w+`),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tokens, err := ReadTokens(split, NewTokenReader(brokenReader("a b")))
+			assert.ErrorIs(t, err, errBroken)
+			assert.Nil(t, tokens)
+		})
+	}
+}
+
+func TestDiffer_DiffReaders(t *testing.T) {
+	d := NewDiffer(SplitSpaces)
+	ds, err := d.DiffReaders(strings.NewReader("a b c"), strings.NewReader("a B c"))
+	require.NoError(t, err)
+	assert.Equal(t, "a [-b-]{+B+} c", ds.WordDiff())
+
+	_, err = d.DiffReaders(strings.NewReader("a b c"), brokenReader("a b"))
+	assert.ErrorIs(t, err, errBroken)
+}
+
+func TestTokenReader_Err(t *testing.T) {
+	tr := NewTokenReader(brokenReader("ab"))
+	assert.NoError(t, tr.Err())
+	token, _ := tr.Until(" ")
+	assert.Equal(t, "ab", token)
+	assert.ErrorIs(t, tr.Err(), errBroken)
+	_, err := tr.ReadRune()
+	assert.ErrorIs(t, err, errBroken, "Reads after an error should fail too")
+}
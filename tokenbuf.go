@@ -3,7 +3,9 @@ package linediff
 import (
 	"github.com/drognisep/runebuffer"
 	"io"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 type Splitter interface {
@@ -16,26 +18,30 @@ func (f SplitterFunc) Split(tr *TokenReader) []string {
 	return f(tr)
 }
 
-var SplitSpaces = SplitterFunc(func(tr *TokenReader) []string {
-	var tokens []string
-	for {
-		token, found := tr.Until(" ")
-		if !found {
-			return tokens
-		}
-		tokens = append(tokens, token)
-		for {
-			space, found := tr.AcceptToken(" ")
-			if !found {
-				break
-			}
-			tokens = append(tokens, space)
-		}
+// SplitSpaces splits input into words and single spaces.
+// Use StreamSpaces to stream each token as it's read instead.
+var SplitSpaces = SplitterFunc(StreamSpaces.Split)
+
+// StreamSpaces reads the next word or single space, so it splits input like SplitSpaces, but streams each token as it's read.
+var StreamSpaces = NextFunc(func(tr *TokenReader) (string, bool) {
+	if space, found := tr.AcceptToken(" "); found {
+		return space, true
 	}
+	return tr.Until(" ")
 })
 
 type TokenReader struct {
 	*runebuffer.RuneBuffer
+	src *errReader
+	// offset counts the runes read with next, for checkpoints.
+	offset int
+	// size is the number of runes the buffer holds, and written counts the runes read into it, including the end of the input.
+	// Runes can be unread back to an offset only while written is at most size past it.
+	size    int
+	written int
+	// bounded is set while reading is limited to runes that can be unread back to floor.
+	bounded bool
+	floor   Checkpoint
 }
 
 func NewStringTokenReader(s string) *TokenReader {
@@ -54,76 +60,193 @@ func NewTokenReaderWithSize(r io.Reader, size int) *TokenReader {
 	if r == nil {
 		panic("nil reader")
 	}
+	src := &errReader{r: r}
 	return &TokenReader{
-		RuneBuffer: runebuffer.NewRuneBufferWithSize(r, size),
+		RuneBuffer: runebuffer.NewRuneBufferWithSize(src, size),
+		src:        src,
+		size:       size,
+	}
+}
+
+// Err returns the first error from the underlying reader, or nil if there hasn't been one.
+// Reaching the end of the input isn't an error.
+// Input is read ahead into a buffer, so Err may return an error before every rune read ahead of it has been consumed.
+func (tr *TokenReader) Err() error {
+	return tr.src.err
+}
+
+// errReader records the first error from reading, other than io.EOF, and returns it from every later read.
+// This way an error ends the input for good, rather than the next read trying again.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
 	}
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// next reads the next rune, returning false at the end of the input, if there's an error, or if reading is limited and the limit is reached.
+// The end of the input is unread, so it's seen again by the next read, and only runes read this way are counted by checkpoints.
+func (tr *TokenReader) next() (rune, bool) {
+	if tr.limited() {
+		return 0, false
+	}
+	r, err := tr.RuneBuffer.ReadRune()
+	if err != nil {
+		return 0, false
+	}
+	if tr.offset == tr.written {
+		tr.written++
+	}
+	if r == 0 {
+		tr.RuneBuffer.UnreadRune()
+		return 0, false
+	}
+	tr.offset++
+	return r, true
+}
+
+// back unreads n runes read with next.
+func (tr *TokenReader) back(n int) {
+	tr.RuneBuffer.UnreadNumRunes(n)
+	tr.offset -= n
+}
+
+// Checkpoint is a position in the input of a TokenReader, to return to with Reset.
+type Checkpoint int
+
+// Mark returns a Checkpoint for the current position, so a splitter can read ahead and Reset back to it without counting runes.
+// Only runes read with TokenReader methods are tracked, so reading with ReadRune or UnreadRune between Mark and Reset isn't undone correctly.
+// Reading further ahead of a Checkpoint than the buffer size means Reset can't return to it.
+func (tr *TokenReader) Mark() Checkpoint {
+	return Checkpoint(tr.offset)
+}
+
+// Reset unreads every rune read since the Checkpoint was marked, returning true if it could.
+// The buffer only holds so many runes, so if the input has been read further ahead of the Checkpoint than the buffer size,
+// then nothing is unread and false is returned.
+func (tr *TokenReader) Reset(c Checkpoint) bool {
+	if tr.written-int(c) > tr.size {
+		return false
+	}
+	if n := tr.offset - int(c); n > 0 {
+		tr.back(n)
+	}
+	return true
+}
+
+// limit stops reading at the last rune that can still be unread back to c, until the returned function is called.
+// Nested limits keep the earliest Checkpoint, so an outer Reset still works after inner reads.
+func (tr *TokenReader) limit(c Checkpoint) (unlimit func()) {
+	bounded, floor := tr.bounded, tr.floor
+	if !bounded || c < floor {
+		tr.bounded, tr.floor = true, c
+	}
+	return func() {
+		tr.bounded, tr.floor = bounded, floor
+	}
+}
+
+// limited returns true if reading the next rune would go past the limit.
+func (tr *TokenReader) limited() bool {
+	return tr.bounded && tr.offset == tr.written && tr.written-int(tr.floor) >= tr.size
+}
+
+// Peek returns up to n of the next runes without reading them, and fewer at the end of the input.
+// At most the buffer size can be peeked.
+func (tr *TokenReader) Peek(n int) string {
+	var buf strings.Builder
+	mark := tr.Mark()
+	defer tr.limit(mark)()
+	for i := 0; i < n; i++ {
+		r, ok := tr.next()
+		if !ok {
+			break
+		}
+		buf.WriteRune(r)
+	}
+	tr.Reset(mark)
+	return buf.String()
 }
 
 // AcceptToken returns exactly the token from the input if it exists.
 // Otherwise, "" and false are returned, and all read runes are unread.
 func (tr *TokenReader) AcceptToken(token string) (string, bool) {
-	rs := []rune(token)
-
-	for i := 0; i < len(rs); i++ {
-		r, err := tr.ReadRune()
-		if err != nil {
-			tr.UnreadNumRunes(i + 1)
-			return "", false
-		}
-		if rs[i] != r {
-			tr.UnreadNumRunes(i + 1)
+	mark := tr.Mark()
+	for _, want := range token {
+		if r, ok := tr.next(); !ok || r != want {
+			tr.Reset(mark)
 			return "", false
 		}
 	}
 	return token, true
 }
 
-// Accept reads runes matching the accept list, returning the read token if any runes were read.
-// The list is split to runes, and all unique runes are included in a match set.
-func (tr *TokenReader) Accept(list string) (string, bool) {
-	rs := []rune(list)
-	matchSet := map[rune]bool{}
+// AcceptFunc reads runes for which f returns true, returning the read token if any runes were read.
+func (tr *TokenReader) AcceptFunc(f func(r rune) bool) (string, bool) {
 	var buf strings.Builder
-	for _, r := range rs {
-		matchSet[r] = true
-	}
 	for {
-		r, err := tr.RuneBuffer.ReadRune()
-		if err != nil {
-			l := buf.Len()
-			return buf.String(), l > 0
+		r, ok := tr.next()
+		if !ok {
+			break
 		}
-		if matchSet[r] {
-			buf.WriteRune(r)
-			continue
+		if !f(r) {
+			tr.back(1)
+			break
 		}
-		tr.RuneBuffer.UnreadRune()
-		l := buf.Len()
-		return buf.String(), l > 0
+		buf.WriteRune(r)
 	}
+	return buf.String(), buf.Len() > 0
+}
+
+// UntilFunc reads until a rune for which f returns true, returning the read token if any runes were read.
+// This can read the entire input into memory if no rune past the current read point matches.
+func (tr *TokenReader) UntilFunc(f func(r rune) bool) (string, bool) {
+	return tr.AcceptFunc(func(r rune) bool {
+		return !f(r)
+	})
+}
+
+// Accept reads runes matching the accept list, returning the read token if any runes were read.
+// The list is split to runes, and all unique runes are included in a match set.
+// Use AcceptFunc with a RuneSet to avoid building the set on each call.
+func (tr *TokenReader) Accept(list string) (string, bool) {
+	return tr.AcceptFunc(NewRuneSet(list).Contains)
 }
 
 // UntilToken returns all from the input runes up to the token if it exists.
 // If the token is not found or the end of the stream is reached, then "" and false are returned, and all read runes are unread.
 // This will return "" and true if the token is the very next set of runes in the stream.
-// This can read the entire input into memory before unreading if the token doesn't exist past the current read point.
+// The token must end within the buffer size of the current position, so that runes can be unread if it's not found.
 func (tr *TokenReader) UntilToken(token string) (string, bool) {
-	keyRune := []rune(token)[0]
-	var buf strings.Builder
-
+	keyRune, _ := utf8.DecodeRuneInString(token)
+	isKey := func(r rune) bool {
+		return r == keyRune
+	}
+	var (
+		buf  strings.Builder
+		mark = tr.Mark()
+	)
+	defer tr.limit(mark)()
 	for {
-		text, found := tr.Until(string(keyRune))
-		if found {
-			buf.WriteString(text)
-		}
-		_, found = tr.AcceptToken(token)
-		if found {
-			tr.UnreadNumRunes(len(token))
+		text, _ := tr.UntilFunc(isKey)
+		buf.WriteString(text)
+		tokenStart := tr.Mark()
+		if _, found := tr.AcceptToken(token); found {
+			tr.Reset(tokenStart)
 			return buf.String(), true
 		}
-		r, err := tr.ReadRune()
-		if err != nil || r == 0 {
-			tr.UnreadNumRunes(buf.Len())
+		r, ok := tr.next()
+		if !ok {
+			tr.Reset(mark)
 			return "", false
 		}
 		buf.WriteRune(r)
@@ -133,25 +256,76 @@ func (tr *TokenReader) UntilToken(token string) (string, bool) {
 // Until reads until a rune in the list string matches, returning the read token if any runes were read.
 // The list is split to runes, and all unique runes are included in a match set.
 // This can read the entire input into memory if the runes do not exist past the current read point.
+// Use UntilFunc with a RuneSet to avoid building the set on each call.
 func (tr *TokenReader) Until(list string) (string, bool) {
-	rs := []rune(list)
-	matchSet := map[rune]bool{}
+	return tr.UntilFunc(NewRuneSet(list).Contains)
+}
+
+// AcceptRegexp reads a match of the regular expression at the current position, returning false and unreading all read runes if there isn't one.
+// Patterns should be anchored with ^ or \A, since otherwise input is read looking for a later match.
+// Matching may need to read past the end of the match, which is unread, so no more than the buffer size is read.
+// If matching reaches that limit, like an unanchored pattern that doesn't match, then there's no match.
+func (tr *TokenReader) AcceptRegexp(re *regexp.Regexp) (string, bool) {
+	rr := &checkpointRuneReader{tr: tr, mark: tr.Mark()}
+	unlimit := tr.limit(rr.mark)
+	loc := re.FindReaderIndex(rr)
+	unlimit()
+	tr.Reset(rr.mark)
+	if loc == nil || loc[0] != 0 || loc[1] == 0 || rr.limited {
+		return "", false
+	}
 	var buf strings.Builder
-	for _, r := range rs {
-		matchSet[r] = true
+	for buf.Len() < loc[1] {
+		r, _ := tr.next()
+		buf.WriteRune(r)
 	}
-	for {
-		r, err := tr.RuneBuffer.ReadRune()
-		if err != nil {
-			l := buf.Len()
-			return buf.String(), l > 0
-		}
-		if r != 0 && !matchSet[r] {
-			buf.WriteRune(r)
+	return buf.String(), true
+}
+
+// checkpointRuneReader reads runes from a TokenReader for the regexp package, which expects an io.RuneReader.
+// Reaching the read limit is reported as the end of the input, and sets limited.
+type checkpointRuneReader struct {
+	tr      *TokenReader
+	mark    Checkpoint
+	limited bool
+}
+
+func (r *checkpointRuneReader) ReadRune() (rune, int, error) {
+	next, ok := r.tr.next()
+	if !ok {
+		r.limited = r.tr.limited()
+		return 0, 0, io.EOF
+	}
+	return next, utf8.RuneLen(next), nil
+}
+
+// RuneSet is a precompiled set of runes, for matching runes with AcceptFunc and UntilFunc in hot loops.
+// ASCII runes are checked with a bit set, and other runes with a map.
+type RuneSet struct {
+	ascii [2]uint64
+	other map[rune]bool
+}
+
+// NewRuneSet creates a RuneSet with each rune in the list.
+func NewRuneSet(list string) *RuneSet {
+	set := new(RuneSet)
+	for _, r := range list {
+		if r < utf8.RuneSelf {
+			set.ascii[r/64] |= 1 << (r % 64)
 			continue
 		}
-		tr.RuneBuffer.UnreadRune()
-		l := buf.Len()
-		return buf.String(), l > 0
+		if set.other == nil {
+			set.other = map[rune]bool{}
+		}
+		set.other[r] = true
+	}
+	return set
+}
+
+// Contains returns true if r is in the set.
+func (s *RuneSet) Contains(r rune) bool {
+	if r >= 0 && r < utf8.RuneSelf {
+		return s.ascii[r/64]&(1<<(r%64)) != 0
 	}
+	return s.other[r]
 }
@@ -2,8 +2,10 @@ package linediff
 
 import (
 	"github.com/stretchr/testify/assert"
+	"regexp"
 	"strings"
 	"testing"
+	"unicode"
 )
 
 func TestSplitSpaces(t *testing.T) {
@@ -23,12 +25,18 @@ func TestSplitSpaces(t *testing.T) {
 			input:  "hello  world",
 			tokens: []string{"hello", " ", " ", "world"},
 		},
+		"Leading space": {
+			input:  " hello",
+			tokens: []string{" ", "hello"},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			tokens := SplitSpaces.Split(NewStringTokenReader(tc.input))
 			assert.Equal(t, tc.tokens, tokens)
+			assert.Equal(t, tc.tokens, SplitSpaces(NewStringTokenReader(tc.input)), "SplitSpaces should be callable as a SplitterFunc")
+			assert.Equal(t, tc.tokens, StreamSpaces.Split(NewStringTokenReader(tc.input)))
 		})
 	}
 }
@@ -226,3 +234,129 @@ func TestTokenReader_UntilToken(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenReader_AcceptFunc(t *testing.T) {
+	tr := NewStringTokenReader("123abc")
+	token, found := tr.AcceptFunc(unicode.IsDigit)
+	assert.True(t, found)
+	assert.Equal(t, "123", token)
+
+	token, found = tr.AcceptFunc(unicode.IsDigit)
+	assert.False(t, found)
+	assert.Equal(t, "", token)
+
+	token, found = tr.UntilFunc(unicode.IsDigit)
+	assert.True(t, found)
+	assert.Equal(t, "abc", token, "UntilFunc should stop at the end of the input")
+
+	_, found = tr.AcceptFunc(func(rune) bool { return true })
+	assert.False(t, found, "The end of the input shouldn't be accepted")
+}
+
+func TestTokenReader_Peek(t *testing.T) {
+	tr := NewStringTokenReader("héllo")
+	assert.Equal(t, "hé", tr.Peek(2))
+	assert.Equal(t, "hé", tr.Peek(2), "Peek shouldn't read runes")
+	assert.Equal(t, "héllo", tr.Peek(10))
+	tr.AcceptToken("hél")
+	assert.Equal(t, "lo", tr.Peek(3))
+	tr.AcceptToken("lo")
+	assert.Equal(t, "", tr.Peek(1))
+}
+
+func TestTokenReader_MarkReset(t *testing.T) {
+	tr := NewStringTokenReader("key = value")
+	mark := tr.Mark()
+	tr.Accept("aekyz")
+	tr.Accept(" =")
+	tr.Reset(mark)
+	token, _ := tr.Until(" ")
+	assert.Equal(t, "key", token)
+
+	mark = tr.Mark()
+	tr.Until("")
+	tr.Reset(mark)
+	assert.Equal(t, " = value", tr.Peek(20), "Reset should work after reaching the end of the input")
+
+	tr.Reset(mark)
+	assert.Equal(t, " = value", tr.Peek(20), "Resetting twice should have no effect")
+}
+
+func TestTokenReader_AcceptRegexp(t *testing.T) {
+	number := regexp.MustCompile(`^\d+(\.\d+)?`)
+	tr := NewStringTokenReader("3.14 is π")
+	token, found := tr.AcceptRegexp(number)
+	assert.True(t, found)
+	assert.Equal(t, "3.14", token)
+
+	_, found = tr.AcceptRegexp(number)
+	assert.False(t, found)
+	assert.Equal(t, " is π", tr.Peek(10), "Runes should be unread if there's no match")
+
+	_, found = tr.AcceptRegexp(regexp.MustCompile(`π`))
+	assert.False(t, found, "Matches after the current position shouldn't be accepted")
+	assert.Equal(t, " is π", tr.Peek(10))
+
+	tr.AcceptToken(" is")
+	token, found = tr.AcceptRegexp(regexp.MustCompile(`^\s*\p{Greek}`))
+	assert.True(t, found)
+	assert.Equal(t, " π", token)
+
+	_, found = tr.AcceptRegexp(regexp.MustCompile(`^x*`))
+	assert.False(t, found, "Empty matches shouldn't be accepted")
+}
+
+func TestTokenReader_LongInput(t *testing.T) {
+	input := strings.Repeat("a", 2000) + "b"
+
+	t.Run("Peek", func(t *testing.T) {
+		tr := NewStringTokenReader(input)
+		assert.Len(t, tr.Peek(1500), 1024, "Peek should be limited to the buffer")
+		assert.Equal(t, input, readAll(tr), "Peek shouldn't read runes")
+	})
+	t.Run("Reset", func(t *testing.T) {
+		tr := NewStringTokenReader(input)
+		mark := tr.Mark()
+		tr.Accept("a")
+		assert.False(t, tr.Reset(mark), "Runes past the buffer size can't be unread")
+		assert.Equal(t, "b", readAll(tr), "A failed Reset shouldn't unread anything")
+
+		tr = NewStringTokenReader(input)
+		tr.Accept("a")
+		mark = tr.Mark()
+		tr.Until("")
+		assert.True(t, tr.Reset(mark))
+		assert.Equal(t, "b", readAll(tr))
+	})
+	t.Run("UntilToken", func(t *testing.T) {
+		tr := NewStringTokenReader(input)
+		_, found := tr.UntilToken("c")
+		assert.False(t, found)
+		_, found = tr.UntilToken("b")
+		assert.False(t, found, "Tokens past the buffer size shouldn't be found")
+		assert.Equal(t, input, readAll(tr), "Runes should be unread if the token isn't found")
+	})
+	t.Run("AcceptRegexp", func(t *testing.T) {
+		tr := NewStringTokenReader(input)
+		_, found := tr.AcceptRegexp(regexp.MustCompile(`c`))
+		assert.False(t, found)
+		_, found = tr.AcceptRegexp(regexp.MustCompile(`^a+b`))
+		assert.False(t, found, "Matches past the buffer size shouldn't be accepted")
+		token, found := tr.AcceptRegexp(regexp.MustCompile(`^a{10}`))
+		assert.True(t, found)
+		assert.Equal(t, strings.Repeat("a", 10), token)
+		assert.Equal(t, input[10:], readAll(tr), "Runes should be unread if there's no match")
+	})
+}
+
+func TestRuneSet(t *testing.T) {
+	set := NewRuneSet(" \t,;é語")
+	for _, r := range " \t,;é語" {
+		assert.True(t, set.Contains(r), "%q should be in the set", r)
+	}
+	for _, r := range "a0\n\x7fè日" {
+		assert.False(t, set.Contains(r), "%q shouldn't be in the set", r)
+	}
+	assert.False(t, set.Contains(-1))
+	assert.False(t, NewRuneSet("").Contains('a'))
+}
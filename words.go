@@ -0,0 +1,64 @@
+package linediff
+
+import (
+	"github.com/rivo/uniseg"
+	"strings"
+	"unicode"
+)
+
+// SplitWords splits input at Unicode word boundaries, as described in UAX #29, so words, numbers, and punctuation are separate tokens.
+// Adjacent whitespace is combined into a single token, including tabs and line breaks.
+// Thai, Chinese, and Japanese text, which doesn't separate words with spaces, is split into words with DefaultDictionary.
+var SplitWords = SplitWordsWith(DefaultDictionary)
+
+// SplitWordsWith works like SplitWords, but splits runs of Thai, Chinese, and Japanese text with the Segmenter,
+// like a Dictionary with domain specific words.
+func SplitWordsWith(seg Segmenter) SplitterFunc {
+	if seg == nil {
+		panic("nil segmenter")
+	}
+	return func(tr *TokenReader) []string {
+		var (
+			tokens []string
+			state  = -1
+			word   string
+			// run collects adjacent words in scripts without spaces, which UAX #29 splits into single characters.
+			run strings.Builder
+		)
+		flush := func() {
+			if run.Len() > 0 {
+				tokens = append(tokens, seg.Segment(run.String())...)
+				run.Reset()
+			}
+		}
+		rest := readAll(tr)
+		for len(rest) > 0 {
+			word, rest, state = uniseg.FirstWordInString(rest, state)
+			if isUnspaced(word) {
+				run.WriteString(word)
+				continue
+			}
+			flush()
+			if n := len(tokens); n > 0 && isSpace(word) && isSpace(tokens[n-1]) {
+				tokens[n-1] += word
+				continue
+			}
+			tokens = append(tokens, word)
+		}
+		flush()
+		return tokens
+	}
+}
+
+// readAll reads the remaining input from the TokenReader.
+func readAll(tr *TokenReader) string {
+	text, _ := tr.UntilFunc(func(rune) bool {
+		return false
+	})
+	return text
+}
+
+// isSpace returns true if s only contains whitespace.
+func isSpace(s string) bool {
+	return len(s) > 0 && strings.TrimFunc(s, unicode.IsSpace) == ""
+}
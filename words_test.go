@@ -0,0 +1,107 @@
+package linediff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens []string
+	}{
+		"No tokens": {
+			input:  "",
+			tokens: nil,
+		},
+		"Punctuation": {
+			input:  "} else;",
+			tokens: []string{"}", " ", "else", ";"},
+		},
+		"Whitespace runs": {
+			input:  "a\t\tb\r\n\nc  d",
+			tokens: []string{"a", "\t\t", "b", "\r\n\n", "c", "  ", "d"},
+		},
+		"Contractions and numbers": {
+			input:  "don't round 3.14",
+			tokens: []string{"don't", " ", "round", " ", "3.14"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := SplitWords.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+		})
+	}
+}
+
+func TestSplitWords_Dictionary(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		tokens []string
+	}{
+		"Thai": {
+			input:  "ผมชอบกินข้าวผัด ครับ",
+			tokens: []string{"ผม", "ชอบ", "กิน", "ข้าวผัด", " ", "ครับ"},
+		},
+		"Thai with unknown words": {
+			input:  "สวัสดีคุณสมศักดิ์",
+			tokens: []string{"สวัสดี", "คุณ", "สมศักดิ์"},
+		},
+		"Chinese": {
+			input:  "我爱北京天安门。今天天气很好",
+			tokens: []string{"我", "爱", "北京", "天安门", "。", "今天", "天气", "很", "好"},
+		},
+		"Traditional Chinese": {
+			input:  "我們明天去臺北",
+			tokens: []string{"我們", "明天", "去", "臺北"},
+		},
+		"Japanese": {
+			input:  "日本語のテキストです。コーヒーを飲みます",
+			tokens: []string{"日本語", "の", "テキスト", "です", "。", "コーヒー", "を", "飲みます"},
+		},
+		"Japanese with unknown Katakana": {
+			input:  "私はラーメンを食べます",
+			tokens: []string{"私", "は", "ラーメン", "を", "食べます"},
+		},
+		"Mixed with Latin": {
+			input:  "Go言語とGo",
+			tokens: []string{"Go", "言", "語", "と", "Go"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens := SplitWords.Split(NewStringTokenReader(tc.input))
+			assert.Equal(t, tc.tokens, tokens)
+			assert.Equal(t, tc.input, strings.Join(tokens, ""))
+		})
+	}
+}
+
+func TestSplitWordsWith(t *testing.T) {
+	split := SplitWordsWith(DefaultDictionary.With("言語", "สมชาย"))
+	assert.Equal(t, []string{"Go", "言語", "と", "Go"}, split.Split(NewStringTokenReader("Go言語とGo")))
+	assert.False(t, DefaultDictionary.Contains("言語"), "With shouldn't change the original dictionary")
+
+	dict, err := ReadDictionary(strings.NewReader("# Words\n北\n\n 北京 \n"))
+	require.NoError(t, err)
+	assert.True(t, dict.Contains("北京"))
+	assert.False(t, dict.Contains("# Words"))
+	assert.Equal(t, []string{"我", "爱", "北京"}, SplitWordsWith(dict).Split(NewStringTokenReader("我爱北京")))
+}
+
+func TestSplitWords_LongInput(t *testing.T) {
+	input := strings.Repeat("word, ", 100)
+	tokens := SplitWords.Split(NewStringTokenReaderWithSize(input, 16))
+	assert.Len(t, tokens, 300)
+	assert.Equal(t, input, strings.Join(tokens, ""))
+}
+
+func TestDiffSplit_Words(t *testing.T) {
+	ds := DiffSplit("if x {\n\treturn;\n}", "if y {\n\treturn nil;\n}", SplitWords)
+	assert.Equal(t, "if [-x-]{+y+} {\n\treturn{+ nil+};\n}", ds.WordDiff())
+}